@@ -0,0 +1,126 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+// Augmenter defines an aggregate of the keys of an AVL tree, such as their
+// sum or a hash of them, that the tree maintains for each of its subtrees as
+// it is modified, so that the aggregate of any range of keys can be computed
+// in O(log n) time (see Tree.Aggregate).
+//
+// A nil aggregate stands for the aggregate of no keys, so that Combine is
+// never called with a nil argument.
+type Augmenter interface {
+	// Value returns the aggregate of the single key. It is called once for
+	// each key as it is inserted into the tree.
+	Value(key Item) interface{}
+
+	// Combine returns the aggregate of the keys that a aggregates followed by
+	// those that b aggregates. It must be associative, and it must not
+	// modify a or b, which remain in use.
+	Combine(a, b interface{}) interface{}
+}
+
+// combine combines the aggregates a and b with aug, where nil stands for the
+// aggregate of no keys.
+func combine(aug Augmenter, a, b interface{}) interface{} {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	return aug.Combine(a, b)
+}
+
+// aggregate returns the aggregate of the AVL subtree rooted with n.
+func (n *treeNode) aggregate() interface{} {
+	if n == nil {
+		return nil
+	}
+	return n.agg
+}
+
+// subtreeAugment recomputes the values and the aggregates of all treeNodes of
+// the AVL subtree rooted with n with aug, or clears them if aug is nil.
+func (n *treeNode) subtreeAugment(aug Augmenter) {
+	if n == nil {
+		return
+	}
+	n.left.subtreeAugment(aug)
+	n.right.subtreeAugment(aug)
+	n.value, n.agg = nil, nil
+	n.update(aug)
+}
+
+// subtreeAggregate returns the aggregate of the keys of the AVL subtree rooted
+// with n that are greater than or equal to lo and less than hi. Below the
+// treeNode where the paths to lo and hi part, it only combines the aggregates
+// of the subtrees that lie entirely within the range, in O(log n) time.
+func (n *treeNode) subtreeAggregate(lo, hi Item, aug Augmenter) interface{} {
+	for n != nil {
+		switch {
+		case lo != nil && n.key.Less(lo):
+			n = n.right
+		case hi != nil && !n.key.Less(hi):
+			n = n.left
+		default:
+			var left, right interface{}
+			for l := n.left; l != nil; { // keys not less than lo, right to left
+				if lo != nil && l.key.Less(lo) {
+					l = l.right
+					continue
+				}
+				left = combine(aug, combine(aug, l.value, l.right.aggregate()), left)
+				l = l.left
+			}
+			for r := n.right; r != nil; { // keys less than hi, left to right
+				if hi != nil && !r.key.Less(hi) {
+					r = r.left
+					continue
+				}
+				right = combine(aug, right, combine(aug, r.left.aggregate(), r.value))
+				r = r.right
+			}
+			return combine(aug, combine(aug, left, n.value), right)
+		}
+	}
+	return nil
+}
+
+// SetAugmenter sets the Augmenter of the AVL tree, computing the aggregates
+// of all of its subtrees in O(n) time; a nil aug removes it. From then on,
+// all operations that modify the tree keep the aggregates up to date, at the
+// cost of calling aug.Combine for each treeNode they update. Trees derived
+// from the tree, such as by Filter, are not augmented, except for those
+// returned by ExtractRange.
+//
+// Since it updates every treeNode, SetAugmenter conflicts with any Txn in
+// progress on the tree.
+func (t *Tree) SetAugmenter(aug Augmenter) {
+	t.stats.aug = aug
+	t.root.subtreeAugment(aug)
+	t.mods++
+}
+
+// Augmenter returns the Augmenter of the AVL tree, or nil if there is none.
+func (t *Tree) Augmenter() Augmenter {
+	return t.stats.aug
+}
+
+// Aggregate returns the aggregate of the keys in the AVL tree that are greater
+// than or equal to lo and less than hi, as defined by the Augmenter of the
+// tree, in O(log n) time. A nil lo or hi leaves the range unbounded on that
+// side. It returns nil if the range is empty, or if the tree has no
+// Augmenter.
+func (t *Tree) Aggregate(lo, hi Item) interface{} {
+	if t.stats.aug == nil {
+		return nil
+	}
+	return t.root.subtreeAggregate(lo, hi, t.stats.aug)
+}
@@ -0,0 +1,135 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// concat is an Augmenter that concatenates the keys, so that the aggregates
+// also depend on their order.
+type concat struct{}
+
+func (concat) Value(key Item) interface{}           { return fmt.Sprintf("%v,", key) }
+func (concat) Combine(a, b interface{}) interface{} { return a.(string) + b.(string) }
+
+// verifyAggregates checks the aggregates of all subtrees of the AVL subtree
+// rooted with n against their keys, and returns the aggregate of n.
+func verifyAggregates(t *testing.T, n *treeNode) string {
+	t.Helper()
+	if n == nil {
+		return ""
+	}
+	expected := verifyAggregates(t, n.left) + fmt.Sprintf("%v,", n.key) + verifyAggregates(t, n.right)
+	if agg, _ := n.agg.(string); agg != expected {
+		t.Fatalf("aggregate of the subtree of %v = %q; expected %q\n", n.key, agg, expected)
+	}
+	return expected
+}
+
+// concatRange concatenates the keys of tree in [lo, hi) one by one.
+func concatRange(tree *Tree, lo, hi Item) interface{} {
+	var s interface{}
+	tree.AscendRange(lo, hi, func(key Item) bool {
+		s = combine(concat{}, s, fmt.Sprintf("%v,", key))
+		return true
+	})
+	return s
+}
+
+func TestAugmenter(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := newIntegerTree(t, r.Perm(100)...)
+	if tree.Aggregate(nil, nil) != nil {
+		t.Errorf("Aggregate() of a tree without an Augmenter = %v; expected nil\n", tree.Aggregate(nil, nil))
+	}
+	tree.SetAugmenter(concat{})
+	verifyAggregates(t, tree.root)
+
+	// All mutation paths keep the aggregates up to date.
+	for i := 0; i < 300; i++ {
+		key := Integer(r.Intn(300))
+		switch r.Intn(8) {
+		case 0, 1:
+			tree.Insert(key)
+		case 2:
+			tree.Delete(key)
+		case 3:
+			tree.PopMin()
+			tree.PopMax()
+		case 4:
+			ops := []Op{{OpInsert, key}, {OpDelete, key + 1}, {OpInsert, key + 2}}
+			if r.Intn(4) == 0 {
+				for k := Integer(0); k < 300; k += 7 {
+					ops = append(ops, Op{OpInsert, key + 3 + k})
+				}
+			}
+			tree.ApplyBatch(ops)
+		case 5:
+			extracted := tree.ExtractRange(key, key+10)
+			verifyAggregates(t, extracted.root)
+			if extracted.Augmenter() == nil {
+				t.Fatalf("ExtractRange() returned a tree without an Augmenter\n")
+			}
+		case 6:
+			tree.DeleteFunc(func(k Item) bool { return k.(Integer)%37 == key%37 })
+		case 7:
+			txn := tree.Begin()
+			txn.Insert(key)
+			txn.Delete(key + 1)
+			txn.Insert(key + 2)
+			if err := txn.Commit(); err != nil {
+				t.Fatalf("\t%v\n", err)
+			}
+		}
+		verifyAVL(t, tree.root)
+		verifyAggregates(t, tree.root)
+
+		lo, hi := Integer(r.Intn(320)-10), Integer(r.Intn(320)-10)
+		if agg, expected := tree.Aggregate(lo, hi), concatRange(tree, lo, hi); agg != expected {
+			t.Fatalf("Aggregate(%v, %v) = %v; expected %v\n", lo, hi, agg, expected)
+		}
+	}
+	if agg, expected := tree.Aggregate(nil, nil), concatRange(tree, nil, nil); agg != expected {
+		t.Errorf("Aggregate() = %v; expected %v\n", agg, expected)
+	}
+
+	tree.SetAugmenter(nil)
+	if tree.Aggregate(nil, nil) != nil || tree.root.agg != nil || tree.root.value != nil {
+		t.Errorf("\tAggregates left behind after removing the Augmenter\n")
+	}
+	tree.Insert(Integer(-1))
+	verifyAVL(t, tree.root)
+}
+
+func TestRankSelect(t *testing.T) {
+	tree := newIntegerTree(t, 50, 10, 40, 20, 30)
+	for i, expected := range []Integer{10, 20, 30, 40, 50} {
+		if key, err := tree.Select(i); err != nil || key != expected {
+			t.Errorf("Select(%d) = %v, %v; expected %v\n", i, key, err, expected)
+		}
+		if rank := tree.Rank(expected); rank != i {
+			t.Errorf("Rank(%v) = %d; expected %d\n", expected, rank, i)
+		}
+	}
+	for key, expected := range map[Integer]int{0: 0, 25: 2, 55: 5} {
+		if rank := tree.Rank(key); rank != expected {
+			t.Errorf("Rank(%v) = %d; expected %d\n", key, rank, expected)
+		}
+	}
+	for _, i := range []int{-1, 5} {
+		if _, err := tree.Select(i); err == nil {
+			t.Errorf("\tExpected an error for Select(%d)!\n", i)
+		} else {
+			t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+		}
+	}
+}
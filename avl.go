@@ -37,19 +37,92 @@ type Item interface {
 	Less(than Item) bool
 }
 
+// counters holds the cumulative operational counters of a Tree, which are
+// reported through Tree.Stats.
+type counters struct {
+	comparisons           uint64
+	insertRotations       uint64
+	insertDoubleRotations uint64
+	deleteRotations       uint64
+	deleteDoubleRotations uint64
+	joinRotations         uint64
+	joinDoubleRotations   uint64
+
+	// aug is the Augmenter of the Tree, if any, which the mutations that
+	// these counters count must apply to the treeNodes they modify.
+	aug Augmenter
+}
+
+// rotationKind is the kind of operation that a rotation rebalances a tree
+// after, by which rotations are counted.
+type rotationKind int
+
+const (
+	insertRotation rotationKind = iota
+	deleteRotation
+	joinRotation
+)
+
+// rotated counts a single or double rotation of the given kind.
+func (c *counters) rotated(kind rotationKind, double bool) {
+	switch {
+	case kind == insertRotation && !double:
+		c.insertRotations++
+	case kind == insertRotation:
+		c.insertDoubleRotations++
+	case kind == deleteRotation && !double:
+		c.deleteRotations++
+	case kind == deleteRotation:
+		c.deleteDoubleRotations++
+	case !double:
+		c.joinRotations++
+	default:
+		c.joinDoubleRotations++
+	}
+}
+
+// less reports whether a is less than b, counting the comparison.
+func (c *counters) less(a, b Item) bool {
+	c.comparisons++
+	return a.Less(b)
+}
+
+// equal reports whether a is equal to b, counting the comparison.
+func (c *counters) equal(a, b Item) bool {
+	c.comparisons++
+	return a.Equal(b)
+}
+
+// add adds the counters in d to c.
+func (c *counters) add(d *counters) {
+	c.comparisons += d.comparisons
+	c.insertRotations += d.insertRotations
+	c.insertDoubleRotations += d.insertDoubleRotations
+	c.deleteRotations += d.deleteRotations
+	c.deleteDoubleRotations += d.deleteDoubleRotations
+	c.joinRotations += d.joinRotations
+	c.joinDoubleRotations += d.joinDoubleRotations
+}
+
 // treeNode represents a single node in the AVL tree.
 type treeNode struct {
 	key         Item
 	left, right *treeNode
 	h           int
+	size        int // number of treeNodes in the subtree
+	keyBytes    int // total keySize of the keys in the subtree
+
+	// The value of the key and the aggregate of the subtree, as defined by
+	// the Augmenter of the tree, if any.
+	value, agg interface{}
 }
 
-// newNode allocates, initializes and returns the address of a new treeNode.
-func newNode(key Item) *treeNode {
-	return &treeNode{
-		key: key,
-		h:   1, // initially inserted as a leaf
-	}
+// newNode allocates, initializes and returns the address of a new treeNode,
+// augmented by aug, which may be nil.
+func newNode(key Item, aug Augmenter) *treeNode {
+	n := &treeNode{key: key} // initially inserted as a leaf
+	n.update(aug)
+	return n
 }
 
 // height returns the height of the subtree rooted with n.
@@ -60,9 +133,41 @@ func (n *treeNode) height() int {
 	return n.h
 }
 
+// subtreeSize returns the number of treeNodes in the subtree rooted with n.
+func (n *treeNode) subtreeSize() int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+// subtreeKeyBytes returns the total size of the keys in the subtree rooted
+// with n, as reported by keySize.
+func (n *treeNode) subtreeKeyBytes() int {
+	if n == nil {
+		return 0
+	}
+	return n.keyBytes
+}
+
+// update updates the height, the size, the key bytes and the aggregate of
+// treeNode n from those of its children, after they or its key have changed.
+// The value of the key is computed once, unless aug is nil.
+func (n *treeNode) update(aug Augmenter) {
+	n.h = 1 + max(n.left.height(), n.right.height())
+	n.size = 1 + n.left.subtreeSize() + n.right.subtreeSize()
+	n.keyBytes = keySize(n.key) + n.left.subtreeKeyBytes() + n.right.subtreeKeyBytes()
+	if aug != nil {
+		if n.value == nil {
+			n.value = aug.Value(n.key)
+		}
+		n.agg = combine(aug, combine(aug, n.left.aggregate(), n.value), n.right.aggregate())
+	}
+}
+
 // subtreeRotateRight performs a right rotation of the subtree rooted with n, and
 // returns a pointer to a treeNode, which is the new root of the subtree.
-func (n *treeNode) subtreeRotateRight() *treeNode {
+func (n *treeNode) subtreeRotateRight(aug Augmenter) *treeNode {
 	m := n.left
 	t2 := m.right
 
@@ -70,16 +175,16 @@ func (n *treeNode) subtreeRotateRight() *treeNode {
 	m.right = n
 	n.left = t2
 
-	// update heights
-	n.h = 1 + max(n.left.height(), n.right.height())
-	m.h = 1 + max(m.left.height(), m.right.height())
+	// update heights, sizes and aggregates
+	n.update(aug)
+	m.update(aug)
 
 	return m
 }
 
 // subtreeRotateLeft performs a left rotation of the subtree rooted with n, and
 // returns a pointer to a treeNode, which is the new root of the subtree.
-func (n *treeNode) subtreeRotateLeft() *treeNode {
+func (n *treeNode) subtreeRotateLeft(aug Augmenter) *treeNode {
 	m := n.right
 	t2 := m.left
 
@@ -87,9 +192,9 @@ func (n *treeNode) subtreeRotateLeft() *treeNode {
 	m.left = n
 	n.right = t2
 
-	// update heights
-	n.h = 1 + max(n.left.height(), n.right.height())
-	m.h = 1 + max(m.left.height(), m.right.height())
+	// update heights, sizes and aggregates
+	n.update(aug)
+	m.update(aug)
 
 	return m
 }
@@ -104,61 +209,68 @@ func (n *treeNode) balanceFactor() int {
 }
 
 // subtreeInsertNode inserts key as a new node in the AVL subtree rooted with n.
-func (n *treeNode) subtreeInsertNode(key Item) (*treeNode, error) {
+func (n *treeNode) subtreeInsertNode(key Item, c *counters) (*treeNode, error) {
 	var err error
 
 	// Step 1: Normal BST insertion
 	if n == nil {
-		return newNode(key), nil
+		return newNode(key, c.aug), nil
 	}
 
-	if key.Less(n.key) {
-		n.left, err = n.left.subtreeInsertNode(key)
-	} else if key.Equal(n.key) {
+	if c.less(key, n.key) {
+		n.left, err = n.left.subtreeInsertNode(key, c)
+	} else if c.equal(key, n.key) {
 		return n, fmt.Errorf("Key already in the tree: %v", key) // no duplicate nodes
 	} else { // if key.Greater(n.key) {
-		n.right, err = n.right.subtreeInsertNode(key)
+		n.right, err = n.right.subtreeInsertNode(key, c)
 	}
 
-	// Step 2: Update the height of this ancestor node
-	n.h = 1 + max(n.left.height(), n.right.height())
+	// Step 2: Update the height and size of this ancestor node
+	n.update(c.aug)
 
 	// Step 3: Check if the node is now unbalanced;
 	//         if it is, handle the 4 possible cases.
 	bal := n.balanceFactor()
 	switch {
 	case bal > 1:
-		if key.Less(n.left.key) { // case left left
-			return n.subtreeRotateRight(), err
+		if c.less(key, n.left.key) { // case left left
+			c.insertRotations++
+			return n.subtreeRotateRight(c.aug), err
 		}
 		// else if key.Greater(n.left.key): // case left right
-		n.left = n.left.subtreeRotateLeft()
-		return n.subtreeRotateRight(), err
+		c.insertDoubleRotations++
+		n.left = n.left.subtreeRotateLeft(c.aug)
+		return n.subtreeRotateRight(c.aug), err
 	case bal < -1:
-		if key.Less(n.right.key) { // case right left
-			n.right = n.right.subtreeRotateRight()
-			return n.subtreeRotateLeft(), err
+		if c.less(key, n.right.key) { // case right left
+			c.insertDoubleRotations++
+			n.right = n.right.subtreeRotateRight(c.aug)
+			return n.subtreeRotateLeft(c.aug), err
 		}
 		// else if key.Greater(n.right.key): // case right right
-		return n.subtreeRotateLeft(), err
+		c.insertRotations++
+		return n.subtreeRotateLeft(c.aug), err
 	}
 
 	return n, err
 }
 
 // subtreeDeleteNode deletes the node associated with key from the AVL subtree
-// rooted with n.
-func (n *treeNode) subtreeDeleteNode(key Item) (*treeNode, error) {
+// rooted with n, and returns its new root along with the deleted key, as it
+// was stored in the subtree.
+func (n *treeNode) subtreeDeleteNode(key Item, c *counters) (*treeNode, Item, error) {
+	var deleted Item
 	var err error
 
 	// Step 1: Normal BST deletion
 	if n == nil {
-		return nil, fmt.Errorf("Key not found in the tree: %v", key)
+		return nil, nil, fmt.Errorf("Key not found in the tree: %v", key)
 	}
 
-	if key.Less(n.key) {
-		n.left, err = n.left.subtreeDeleteNode(key)
-	} else if key.Equal(n.key) { // this is the treeNode to be deleted
+	if c.less(key, n.key) {
+		n.left, deleted, err = n.left.subtreeDeleteNode(key, c)
+	} else if c.equal(key, n.key) { // this is the treeNode to be deleted
+		deleted = n.key
 		if n.left == nil || n.right == nil { // case of having < 2 children
 			var tmp *treeNode
 			if n.left == nil {
@@ -177,20 +289,29 @@ func (n *treeNode) subtreeDeleteNode(key Item) (*treeNode, error) {
 			// get the inorder successor (smallest in the right subtree):
 			tmp := n.right.subtreeMin()
 			// copy its data to us:
-			n.key = tmp.key
+			n.key, n.value = tmp.key, tmp.value
 			// delete the inorder successor:
-			n.right, err = n.right.subtreeDeleteNode(tmp.key)
+			n.right, _, err = n.right.subtreeDeleteNode(tmp.key, c)
 		}
 	} else { // if key.Greater(n.key) {
-		n.right, err = n.right.subtreeDeleteNode(key)
+		n.right, deleted, err = n.right.subtreeDeleteNode(key, c)
 	}
 	// If the tree had only 1 node, then return
 	if n == nil {
-		return n, err
+		return n, deleted, err
 	}
 
-	// Step 2: Update the height of the node
-	n.h = 1 + max(n.left.height(), n.right.height())
+	// Steps 2 & 3: Update the height of the node and rebalance it
+	return n.rebalance(c, deleteRotation), deleted, err
+}
+
+// rebalance updates the height of treeNode n, whose subtrees are both
+// balanced but may differ in height by 2 (e.g. after a deletion), and
+// rebalances it, counting any single or double rotation in c as a rotation
+// of the given kind. It returns the new root of the subtree rooted with n.
+func (n *treeNode) rebalance(c *counters, kind rotationKind) *treeNode {
+	// Step 2: Update the height and size of the node
+	n.update(c.aug)
 
 	// Step 3: Check if the node is now unbalanced;
 	//         if it is, handle the 4 possible cases.
@@ -198,21 +319,49 @@ func (n *treeNode) subtreeDeleteNode(key Item) (*treeNode, error) {
 	switch {
 	case bal > 1:
 		if n.left.balanceFactor() >= 0 { // case left left
-			return n.subtreeRotateRight(), err
+			c.rotated(kind, false)
+			return n.subtreeRotateRight(c.aug)
 		}
 		// else if n.left.balanceFactor() < 0: // case left right
-		n.left = n.left.subtreeRotateLeft()
-		return n.subtreeRotateRight(), err
+		c.rotated(kind, true)
+		n.left = n.left.subtreeRotateLeft(c.aug)
+		return n.subtreeRotateRight(c.aug)
 	case bal < -1:
 		if n.right.balanceFactor() <= 0 { // case right right
-			return n.subtreeRotateLeft(), err
+			c.rotated(kind, false)
+			return n.subtreeRotateLeft(c.aug)
 		}
 		// else if n.right.balanceFactor() > 0: // case right left
-		n.right = n.right.subtreeRotateRight()
-		return n.subtreeRotateLeft(), err
+		c.rotated(kind, true)
+		n.right = n.right.subtreeRotateRight(c.aug)
+		return n.subtreeRotateLeft(c.aug)
 	}
 
-	return n, err
+	return n
+}
+
+// subtreeDeleteMin deletes the treeNode associated with the minimum key from
+// the non-empty AVL subtree rooted with n. It returns the new root of the
+// subtree and the deleted treeNode. Rotations are counted as in rebalance.
+func (n *treeNode) subtreeDeleteMin(c *counters, kind rotationKind) (*treeNode, *treeNode) {
+	if n.left == nil {
+		return n.right, n
+	}
+	var min *treeNode
+	n.left, min = n.left.subtreeDeleteMin(c, kind)
+	return n.rebalance(c, kind), min
+}
+
+// subtreeDeleteMax deletes the treeNode associated with the maximum key from
+// the non-empty AVL subtree rooted with n. It returns the new root of the
+// subtree and the deleted treeNode. Rotations are counted as in rebalance.
+func (n *treeNode) subtreeDeleteMax(c *counters, kind rotationKind) (*treeNode, *treeNode) {
+	if n.right == nil {
+		return n.left, n
+	}
+	var max *treeNode
+	n.right, max = n.right.subtreeDeleteMax(c, kind)
+	return n.rebalance(c, kind), max
 }
 
 // subtreeMin returns the treeNode associated with the minimum key currently in
@@ -235,6 +384,79 @@ func (n *treeNode) subtreeMax() *treeNode {
 	return curr
 }
 
+// subtreeSelect returns the treeNode associated with the i-th least key in
+// the AVL subtree rooted with n, counting from 0, or nil if there is no such
+// key. It takes O(log n) time, using the sizes of the subtrees.
+func (n *treeNode) subtreeSelect(i int) *treeNode {
+	curr := n
+	for curr != nil {
+		l := curr.left.subtreeSize()
+		switch {
+		case i < l:
+			curr = curr.left
+		case i == l:
+			return curr
+		default:
+			i -= l + 1
+			curr = curr.right
+		}
+	}
+	return nil
+}
+
+// subtreeRank returns the number of keys in the AVL subtree rooted with n that
+// are less than key, in O(log n) time.
+func (n *treeNode) subtreeRank(key Item) int {
+	rank := 0
+	for curr := n; curr != nil; {
+		if curr.key.Less(key) {
+			rank += curr.left.subtreeSize() + 1
+			curr = curr.right
+		} else {
+			curr = curr.left
+		}
+	}
+	return rank
+}
+
+// subtreeSearch returns the treeNode associated with key in the AVL subtree
+// rooted with n, or nil if there is no such treeNode.
+func (n *treeNode) subtreeSearch(key Item) *treeNode {
+	curr := n
+	for curr != nil {
+		if key.Less(curr.key) {
+			curr = curr.left
+		} else if key.Equal(curr.key) {
+			return curr
+		} else { // if key.Greater(curr.key) {
+			curr = curr.right
+		}
+	}
+	return nil
+}
+
+// subtreeAscendRange calls visit for each Item in the AVL subtree rooted with
+// n that lies in the range [lo, hi), in ascending order. A nil lo or hi leaves
+// the range unbounded on that side. It returns false if the traversal was
+// stopped, either by visit or by reaching hi.
+func (n *treeNode) subtreeAscendRange(lo, hi Item, visit func(Item) bool) bool {
+	if n == nil {
+		return true
+	}
+	if lo == nil || !n.key.Less(lo) {
+		if !n.left.subtreeAscendRange(lo, hi, visit) {
+			return false
+		}
+		if hi != nil && !n.key.Less(hi) {
+			return false
+		}
+		if !visit(n.key) {
+			return false
+		}
+	}
+	return n.right.subtreeAscendRange(lo, hi, visit)
+}
+
 // subtreeInOrder returns a slice of all Items currently in the AVL sub-tree
 // rooted by n, by performing an in-order traversal of its nodes.
 func (n *treeNode) subtreeInOrder() []Item {
@@ -262,8 +484,13 @@ func (n *treeNode) subtreePreOrder() []Item {
 
 // Tree is the exported struct for interacting with the AVL tree.
 type Tree struct {
-	root *treeNode
-	size int
+	root  *treeNode
+	size  int
+	stats counters
+
+	keyBytes int    // total size of the keys, as reported by Sizer
+	memLimit int    // soft memory limit in bytes, or 0 if there is none
+	mods     uint64 // number of modifications, to detect conflicting Txns
 }
 
 // NewTree creates a new empty AVL tree.
@@ -278,10 +505,16 @@ func (t *Tree) Size() int {
 
 // Insert inserts a key into the AVL tree and returns an error value, which is
 // non-nil if the key already exists in the tree (i.e. duplicate keys are not
-// supported).
+// supported), or if inserting it would exceed the memory limit of the tree
+// (see SetMemoryLimit).
 func (t *Tree) Insert(key Item) (err error) {
-	if t.root, err = t.root.subtreeInsertNode(key); err == nil {
+	if err = checkMemoryLimit(t.root, t.size, t.keyBytes, t.memLimit, key); err != nil {
+		return
+	}
+	if t.root, err = t.root.subtreeInsertNode(key, &t.stats); err == nil {
 		t.size++
+		t.keyBytes += keySize(key)
+		t.mods++
 	}
 	return
 }
@@ -289,12 +522,31 @@ func (t *Tree) Insert(key Item) (err error) {
 // Delete removes a key from the AVL tree and returns an error value, which is
 // non-nil if the key doesn't exist in the tree.
 func (t *Tree) Delete(key Item) (err error) {
-	if t.root, err = t.root.subtreeDeleteNode(key); err == nil {
+	var deleted Item
+	if t.root, deleted, err = t.root.subtreeDeleteNode(key, &t.stats); err == nil {
 		t.size--
+		t.keyBytes -= keySize(deleted)
+		t.mods++
 	}
 	return
 }
 
+// Search looks up key in the AVL tree and returns the Item stored in it that
+// is equal to key, along with an error value, which is non-nil if the key
+// doesn't exist in the tree.
+func (t *Tree) Search(key Item) (Item, error) {
+	n := t.root.subtreeSearch(key)
+	if n == nil {
+		return nil, fmt.Errorf("Key not found in the tree: %v", key)
+	}
+	return n.key, nil
+}
+
+// Contains reports whether key exists in the AVL tree.
+func (t *Tree) Contains(key Item) bool {
+	return t.root.subtreeSearch(key) != nil
+}
+
 // Min returns the minimum key in the AVL tree and an error value. If the tree
 // is empty, the error value is non-nil and the result should not be trusted.
 func (t *Tree) Min() (Item, error) {
@@ -330,6 +582,31 @@ func (t *Tree) PreOrder() []Item {
 	return t.root.subtreePreOrder()
 }
 
+// AscendRange calls visit for each Item in the AVL tree that is greater than
+// or equal to lo and less than hi, in ascending order, until visit returns
+// false. A nil lo or hi leaves the range unbounded on that side, so that
+// AscendRange(nil, nil, visit) visits all Items in the tree.
+func (t *Tree) AscendRange(lo, hi Item, visit func(Item) bool) {
+	t.root.subtreeAscendRange(lo, hi, visit)
+}
+
+// Rank returns the number of keys in the AVL tree that are less than key,
+// whether key exists in the tree or not, in O(log n) time.
+func (t *Tree) Rank(key Item) int {
+	return t.root.subtreeRank(key)
+}
+
+// Select returns the i-th least key in the AVL tree, counting from 0, in
+// O(log n) time, along with an error value, which is non-nil if i is out of
+// range.
+func (t *Tree) Select(i int) (Item, error) {
+	n := t.root.subtreeSelect(i)
+	if i < 0 || n == nil {
+		return nil, fmt.Errorf("Index out of range: %d", i)
+	}
+	return n.key, nil
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a
@@ -419,3 +419,81 @@ func TestSize(t *testing.T) {
 		t.Logf("\t ^ Inorder: %v\n", inOrder(t, tree.root))
 	}
 }
+
+func TestSearch(t *testing.T) {
+	tree := NewTree()
+
+	for _, key := range []Integer{9, 5, 10, 0, 6, 11, -1, 1, 2} {
+		if err := tree.Insert(key); err != nil {
+			t.Errorf("\t%v\n", err)
+		}
+	}
+	for _, key := range []Integer{9, 5, 10, 0, 6, 11, -1, 1, 2} {
+		item, err := tree.Search(key)
+		if err != nil {
+			t.Errorf("\t%v\n", err)
+		} else if item != key {
+			t.Errorf("tree.Search(%d) = %d; expected %d\n", key, item, key)
+		}
+		if !tree.Contains(key) {
+			t.Errorf("tree.Contains(%d) = false; expected true\n", key)
+		}
+	}
+
+	if _, err := tree.Search(Integer(42)); err == nil {
+		t.Errorf("\tExpected an error!\n")
+	} else {
+		t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+	}
+	if tree.Contains(Integer(42)) {
+		t.Errorf("tree.Contains(42) = true; expected false\n")
+	}
+}
+
+func TestAscendRange(t *testing.T) {
+	tree := NewTree()
+
+	for i := 0; i < 100; i += 2 {
+		if err := tree.Insert(Integer(i)); err != nil {
+			t.Errorf("\t%v\n", err)
+		}
+	}
+
+	for _, tc := range []struct {
+		lo, hi   Item
+		expected []Integer
+	}{
+		{Integer(10), Integer(20), []Integer{10, 12, 14, 16, 18}},
+		{Integer(11), Integer(19), []Integer{12, 14, 16, 18}},
+		{nil, Integer(5), []Integer{0, 2, 4}},
+		{Integer(95), nil, []Integer{96, 98}},
+		{Integer(20), Integer(20), nil},
+		{Integer(200), nil, nil},
+	} {
+		var got []Integer
+		tree.AscendRange(tc.lo, tc.hi, func(item Item) bool {
+			got = append(got, item.(Integer))
+			return true
+		})
+		if len(got) != len(tc.expected) {
+			t.Errorf("AscendRange(%v, %v) = %v; expected %v\n", tc.lo, tc.hi, got, tc.expected)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.expected[i] {
+				t.Errorf("AscendRange(%v, %v) = %v; expected %v\n", tc.lo, tc.hi, got, tc.expected)
+				break
+			}
+		}
+	}
+
+	// Stop early.
+	count := 0
+	tree.AscendRange(nil, nil, func(Item) bool {
+		count++
+		return count < 3
+	})
+	if count != 3 {
+		t.Errorf("visit called %d times; expected 3\n", count)
+	}
+}
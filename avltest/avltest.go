@@ -0,0 +1,289 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+// Package avltest provides reusable tests for goavl trees, for types that
+// wrap them and for user-provided Item implementations.
+//
+// Trees are exercised by model-based tests, which perform random operations
+// both on the tree under test and on a reference sorted slice (Model), and
+// check that the two always agree. The same checks are available as fuzz
+// targets. Item implementations are checked for the consistency of their Less
+// and Equal methods, which the tree relies on.
+package avltest
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/ckatsak/goavl"
+)
+
+// Integer is a simple Item of int, handy for testing.
+type Integer int
+
+// Equal implements goavl.Item.
+func (i Integer) Equal(j goavl.Item) bool {
+	return i == j.(Integer)
+}
+
+// Less implements goavl.Item.
+func (i Integer) Less(j goavl.Item) bool {
+	return i < j.(Integer)
+}
+
+// Tree is the interface of the tree-like implementations that can be tested
+// by this package. *goavl.Tree satisfies it.
+//
+// If a Tree also has a Height() int method, its height is checked against the
+// maximum height of an AVL tree of the same size.
+type Tree interface {
+	Insert(key goavl.Item) error
+	Delete(key goavl.Item) error
+	Min() (goavl.Item, error)
+	Max() (goavl.Item, error)
+	Size() int
+	InOrder() []goavl.Item
+}
+
+// Compile time check that *goavl.Tree satisfies the Tree interface.
+var _ Tree = goavl.NewTree()
+
+// Populate inserts size random Integers into tree and returns them in the
+// order they were inserted. Duplicate random numbers are skipped.
+func Populate(t testing.TB, tree Tree, r *rand.Rand, size int) []goavl.Item {
+	t.Helper()
+	keys := make([]goavl.Item, 0, size)
+	seen := make(map[Integer]bool, size)
+	for len(keys) < size {
+		key := Integer(r.Int())
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if err := tree.Insert(key); err != nil {
+			t.Fatalf("Insert(%v): %v", key, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// VerifyTraversal checks that the in-order traversal of tree consists of the
+// given keys, in ascending order. The keys need not be sorted.
+func VerifyTraversal(t testing.TB, tree Tree, keys []goavl.Item) {
+	t.Helper()
+	sorted := append([]goavl.Item{}, keys...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Less(sorted[j]) })
+
+	traversal := tree.InOrder()
+	if len(traversal) != len(sorted) {
+		t.Fatalf("len(InOrder()) = %d; expected %d", len(traversal), len(sorted))
+	}
+	for i := range sorted {
+		if !traversal[i].Equal(sorted[i]) {
+			t.Fatalf("InOrder()[%d] = %v; expected %v", i, traversal[i], sorted[i])
+		}
+	}
+}
+
+// Model is a reference implementation of Tree, backed by a sorted slice.
+type Model struct {
+	items []goavl.Item
+}
+
+// search returns the index of the first item in m that is not less than key,
+// and whether it is equal to key.
+func (m *Model) search(key goavl.Item) (int, bool) {
+	i := sort.Search(len(m.items), func(i int) bool { return !m.items[i].Less(key) })
+	return i, i < len(m.items) && m.items[i].Equal(key)
+}
+
+// Insert implements Tree.
+func (m *Model) Insert(key goavl.Item) error {
+	i, found := m.search(key)
+	if found {
+		return errDuplicate
+	}
+	m.items = append(m.items, nil)
+	copy(m.items[i+1:], m.items[i:])
+	m.items[i] = key
+	return nil
+}
+
+// Delete implements Tree.
+func (m *Model) Delete(key goavl.Item) error {
+	i, found := m.search(key)
+	if !found {
+		return errNotFound
+	}
+	m.items = append(m.items[:i], m.items[i+1:]...)
+	return nil
+}
+
+// Min implements Tree.
+func (m *Model) Min() (goavl.Item, error) {
+	if len(m.items) == 0 {
+		return nil, errEmpty
+	}
+	return m.items[0], nil
+}
+
+// Max implements Tree.
+func (m *Model) Max() (goavl.Item, error) {
+	if len(m.items) == 0 {
+		return nil, errEmpty
+	}
+	return m.items[len(m.items)-1], nil
+}
+
+// Size implements Tree.
+func (m *Model) Size() int {
+	return len(m.items)
+}
+
+// InOrder implements Tree.
+func (m *Model) InOrder() []goavl.Item {
+	return append([]goavl.Item{}, m.items...)
+}
+
+// modelError is the type of the errors returned by Model.
+type modelError string
+
+func (e modelError) Error() string {
+	return string(e)
+}
+
+const (
+	errDuplicate = modelError("Key already in the model")
+	errNotFound  = modelError("Key not found in the model")
+	errEmpty     = modelError("Empty model")
+)
+
+// Operations performed by Run and RunOps.
+const (
+	opInsert = iota
+	opDelete
+	opMin
+	opMax
+	numOps
+)
+
+// Run performs n random operations on tree, with keys generated by gen, and
+// checks after each one of them that tree agrees with a Model. Insertions are
+// twice as likely as deletions, so that tree grows over time. If gen is nil,
+// Integers in [0, n) are generated.
+func Run(t testing.TB, tree Tree, r *rand.Rand, n int, gen func(*rand.Rand) goavl.Item) {
+	t.Helper()
+	if gen == nil {
+		gen = func(r *rand.Rand) goavl.Item { return Integer(r.Intn(n)) }
+	}
+	model := &Model{}
+	for i := 0; i < n; i++ {
+		op := opInsert
+		switch x := r.Intn(6); {
+		case x < 3:
+		case x < 4:
+			op = opDelete
+		case x < 5:
+			op = opMin
+		default:
+			op = opMax
+		}
+		if !step(t, tree, model, op, gen(r)) {
+			return
+		}
+	}
+	check(t, tree, model)
+}
+
+// RunOps decodes data as a sequence of operations, performs them on tree and
+// checks after each one of them that tree agrees with a Model. Each pair of
+// bytes of data encodes an operation and a small Integer key, so that any
+// byte slice is a valid input; this makes RunOps suitable for fuzzing.
+func RunOps(t testing.TB, tree Tree, data []byte) {
+	t.Helper()
+	model := &Model{}
+	for i := 0; i+1 < len(data); i += 2 {
+		if !step(t, tree, model, int(data[i])%numOps, Integer(int8(data[i+1]))) {
+			return
+		}
+	}
+	check(t, tree, model)
+}
+
+// Fuzz runs RunOps as a fuzz target against the trees returned by newTree,
+// e.g.:
+//
+//	func FuzzTree(f *testing.F) {
+//		avltest.Fuzz(f, func() avltest.Tree { return goavl.NewTree() })
+//	}
+func Fuzz(f *testing.F, newTree func() Tree) {
+	f.Add([]byte{})
+	f.Add([]byte{opInsert, 1, opInsert, 2, opInsert, 3, opDelete, 2, opMin, 0, opMax, 0})
+	f.Add([]byte{opInsert, 5, opInsert, 5, opDelete, 7, opDelete, 5, opMin, 0})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		RunOps(t, newTree(), data)
+	})
+}
+
+// step performs op with key on both tree and model, and reports whether they
+// agree on its outcome.
+func step(t testing.TB, tree Tree, model *Model, op int, key goavl.Item) bool {
+	t.Helper()
+	var name string
+	var got, expected goavl.Item
+	var gotErr, expectedErr error
+	switch op {
+	case opInsert:
+		name = "Insert"
+		gotErr, expectedErr = tree.Insert(key), model.Insert(key)
+	case opDelete:
+		name = "Delete"
+		gotErr, expectedErr = tree.Delete(key), model.Delete(key)
+	case opMin:
+		name, key = "Min", nil
+		got, gotErr = tree.Min()
+		expected, expectedErr = model.Min()
+	case opMax:
+		name, key = "Max", nil
+		got, gotErr = tree.Max()
+		expected, expectedErr = model.Max()
+	}
+	if (gotErr == nil) != (expectedErr == nil) {
+		t.Errorf("%s(%v) returned error %v; expected %v", name, key, gotErr, expectedErr)
+		return false
+	}
+	if expectedErr == nil && expected != nil && !got.Equal(expected) {
+		t.Errorf("%s() = %v; expected %v", name, got, expected)
+		return false
+	}
+	if tree.Size() != model.Size() {
+		t.Errorf("Size() = %d after %s(%v); expected %d", tree.Size(), name, key, model.Size())
+		return false
+	}
+	return true
+}
+
+// check checks that the contents and the height of tree agree with model.
+func check(t testing.TB, tree Tree, model *Model) {
+	t.Helper()
+	VerifyTraversal(t, tree, model.items)
+	if h, ok := tree.(interface{ Height() int }); ok {
+		if bound := MaxHeight(tree.Size()); h.Height() > bound {
+			t.Errorf("Height() = %d; AVL trees of size %d are at most %d high", h.Height(), tree.Size(), bound)
+		}
+	}
+}
+
+// MaxHeight returns an upper bound of the height of an AVL tree of the given
+// size, as established by Adelson-Velsky and Landis.
+func MaxHeight(size int) int {
+	return int(math.Floor(1.4405*math.Log2(float64(size)+2) - 0.3277))
+}
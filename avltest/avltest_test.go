@@ -0,0 +1,93 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package avltest
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/ckatsak/goavl"
+)
+
+// recorder is a testing.TB that records failures instead of reporting them.
+type recorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recorder) Helper()                                   {}
+func (r *recorder) Errorf(format string, args ...interface{}) { r.failed = true }
+func (r *recorder) Fatalf(format string, args ...interface{}) { r.failed = true }
+
+func TestPopulate(t *testing.T) {
+	tree := goavl.NewTree()
+	keys := Populate(t, tree, rand.New(rand.NewSource(42)), 1<<10)
+	if tree.Size() != len(keys) {
+		t.Errorf("tree.Size() = %d; expected %d\n", tree.Size(), len(keys))
+	}
+	VerifyTraversal(t, tree, keys)
+}
+
+func TestRun(t *testing.T) {
+	Run(t, goavl.NewTree(), rand.New(rand.NewSource(42)), 1<<14, nil)
+}
+
+func TestRunModel(t *testing.T) {
+	// The Model should trivially agree with itself.
+	Run(t, &Model{}, rand.New(rand.NewSource(42)), 1<<10, nil)
+}
+
+// brokenTree loses every third inserted key.
+type brokenTree struct {
+	*goavl.Tree
+	inserts int
+}
+
+func (b *brokenTree) Insert(key goavl.Item) error {
+	if b.inserts++; b.inserts%3 == 0 {
+		return nil
+	}
+	return b.Tree.Insert(key)
+}
+
+func TestRunDetectsBrokenTree(t *testing.T) {
+	r := &recorder{TB: t}
+	Run(r, &brokenTree{Tree: goavl.NewTree()}, rand.New(rand.NewSource(42)), 100, nil)
+	if !r.failed {
+		t.Errorf("\tExpected a failure for the broken tree!\n")
+	}
+}
+
+func FuzzTree(f *testing.F) {
+	Fuzz(f, func() Tree { return goavl.NewTree() })
+}
+
+func TestCheckOrdering(t *testing.T) {
+	CheckOrdering(t, []goavl.Item{Integer(-1), Integer(0), Integer(0), Integer(1), Integer(42)})
+}
+
+// sloppy considers all numbers within 1 of each other equal, which is not a
+// transitive equivalence.
+type sloppy int
+
+func (s sloppy) Equal(to goavl.Item) bool {
+	d := s - to.(sloppy)
+	return -1 <= d && d <= 1
+}
+func (s sloppy) Less(than goavl.Item) bool {
+	return s < than.(sloppy)-1
+}
+
+func TestCheckOrderingDetectsInconsistency(t *testing.T) {
+	r := &recorder{TB: t}
+	CheckOrdering(r, []goavl.Item{sloppy(0), sloppy(1), sloppy(2)})
+	if !r.failed {
+		t.Errorf("\tExpected a failure for the inconsistent Item!\n")
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package avltest
+
+import (
+	"testing"
+
+	"github.com/ckatsak/goavl"
+)
+
+// CheckOrdering checks that the Less and Equal methods of the given Items
+// define a strict weak ordering that is consistent with equality, which is
+// what the tree relies on. In particular, for all a, b and c in items:
+//
+//   - a.Equal(a), and a.Equal(b) is the same as b.Equal(a);
+//   - exactly one of a.Less(b), b.Less(a) and a.Equal(b) holds;
+//   - a.Less(b) and b.Less(c) imply a.Less(c);
+//   - a.Equal(b) and b.Equal(c) imply a.Equal(c).
+//
+// Since all triples of items are examined, items should be a small but
+// representative sample, including any corner cases (e.g. zero values,
+// extreme values, duplicates).
+func CheckOrdering(t testing.TB, items []goavl.Item) {
+	t.Helper()
+	for _, a := range items {
+		if !a.Equal(a) {
+			t.Errorf("%v.Equal(%v) is false", a, a)
+		}
+		if a.Less(a) {
+			t.Errorf("%v.Less(%v) is true", a, a)
+		}
+		for _, b := range items {
+			if a.Equal(b) != b.Equal(a) {
+				t.Errorf("%v.Equal(%v) is %t, but %v.Equal(%v) is %t", a, b, a.Equal(b), b, a, b.Equal(a))
+			}
+			holds := 0
+			for _, ok := range []bool{a.Less(b), b.Less(a), a.Equal(b)} {
+				if ok {
+					holds++
+				}
+			}
+			if holds != 1 {
+				t.Errorf("%v.Less(%v) is %t, %v.Less(%v) is %t and %v.Equal(%v) is %t; exactly one should be true",
+					a, b, a.Less(b), b, a, b.Less(a), a, b, a.Equal(b))
+			}
+			for _, c := range items {
+				if a.Less(b) && b.Less(c) && !a.Less(c) {
+					t.Errorf("%v < %v < %v, but %v.Less(%v) is false", a, b, c, a, c)
+				}
+				if a.Equal(b) && b.Equal(c) && !a.Equal(c) {
+					t.Errorf("%v == %v == %v, but %v.Equal(%v) is false", a, b, c, a, c)
+				}
+			}
+		}
+	}
+}
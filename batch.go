@@ -0,0 +1,142 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// OpKind is the kind of an Op.
+type OpKind int
+
+// The kinds of operations that an Op may apply.
+const (
+	OpInsert OpKind = iota
+	OpDelete
+)
+
+// Op is an operation of a batch that is applied to an AVL tree by ApplyBatch.
+type Op struct {
+	Kind OpKind
+	Key  Item
+}
+
+// ApplyBatch applies the given operations to the AVL tree, as if by calling
+// Insert or Delete for each of them in turn. The operations must be sorted by
+// their keys, in ascending order; operations on equal keys are applied in the
+// order they appear in ops.
+//
+// It returns a slice holding the error value of each operation, which is nil
+// if the operation succeeded, as well as all non-nil ones joined into a single
+// error value. If ops is not sorted, nothing is applied, the slice is nil and
+// the error value reports it.
+//
+// Instead of descending the tree once per operation, ApplyBatch splits the
+// batch around the root of the tree, applies each part to the respective
+// subtree recursively, and joins the results back. If the batch is large
+// compared to the tree, the tree is rebuilt out of its keys merged with the
+// batch instead, in linear time.
+func (t *Tree) ApplyBatch(ops []Op) ([]error, error) {
+	for i := 1; i < len(ops); i++ {
+		if ops[i].Key.Less(ops[i-1].Key) {
+			return nil, fmt.Errorf("Batch not sorted: %v is less than %v", ops[i].Key, ops[i-1].Key)
+		}
+	}
+
+	errs := make([]error, len(ops))
+	t.mods++
+	if len(ops)*batchRebuildRatio >= t.size {
+		nodes := t.root.subtreeFilter(func(Item) bool { return true }, make([]*treeNode, 0, t.size))
+		t.root = buildBalanced(mergeBatch(nodes, ops, errs, &t.stats), t.stats.aug)
+	} else {
+		t.root = t.root.subtreeApplyBatch(ops, errs, &t.stats)
+	}
+	t.size, t.keyBytes = t.root.subtreeSize(), t.root.subtreeKeyBytes()
+	return errs, errors.Join(errs...)
+}
+
+// batchRebuildRatio is the ratio of the size of the tree to the length of a
+// batch, at or below which ApplyBatch rebuilds the tree.
+const batchRebuildRatio = 4
+
+// subtreeApplyBatch applies the sorted ops to the AVL subtree rooted with n,
+// storing their error values in errs, and returns its new root.
+func (n *treeNode) subtreeApplyBatch(ops []Op, errs []error, c *counters) *treeNode {
+	if len(ops) == 0 {
+		return n
+	}
+	if n == nil {
+		return buildBalanced(mergeBatch(nil, ops, errs, c), c.aug)
+	}
+
+	i := sort.Search(len(ops), func(k int) bool { return !c.less(ops[k].Key, n.key) })
+	j := i + sort.Search(len(ops)-i, func(k int) bool { return c.less(n.key, ops[i+k].Key) })
+	left := n.left.subtreeApplyBatch(ops[:i], errs[:i], c)
+	right := n.right.subtreeApplyBatch(ops[j:], errs[j:], c)
+	if m := applyGroup(n, ops[i:j], errs[i:j], c.aug); m != nil {
+		return join(left, m, right, c)
+	}
+	return join2(left, right, c)
+}
+
+// mergeBatch applies the sorted ops to the given sorted treeNodes, storing
+// their error values in errs, and returns the resulting sorted treeNodes.
+func mergeBatch(nodes []*treeNode, ops []Op, errs []error, c *counters) []*treeNode {
+	ret := make([]*treeNode, 0, len(nodes)+len(ops))
+	i := 0
+	for k := 0; k < len(ops); {
+		for i < len(nodes) && c.less(nodes[i].key, ops[k].Key) {
+			ret = append(ret, nodes[i])
+			i++
+		}
+		var n *treeNode
+		if i < len(nodes) && c.equal(nodes[i].key, ops[k].Key) {
+			n = nodes[i]
+			i++
+		}
+		end := k + 1
+		for end < len(ops) && c.equal(ops[end].Key, ops[k].Key) {
+			end++
+		}
+		if n = applyGroup(n, ops[k:end], errs[k:end], c.aug); n != nil {
+			ret = append(ret, n)
+		}
+		k = end
+	}
+	return append(ret, nodes[i:]...)
+}
+
+// applyGroup applies ops, which are all on equal keys, in turn, to the
+// treeNode n that holds their key, or to nil if there is no such treeNode. It
+// stores their error values in errs, and returns the treeNode that holds
+// their key afterwards, or nil if there is none, augmented by aug if it is
+// new. The returned treeNode's children must be ignored.
+func applyGroup(n *treeNode, ops []Op, errs []error, aug Augmenter) *treeNode {
+	for i, op := range ops {
+		switch op.Kind {
+		case OpInsert:
+			if n != nil {
+				errs[i] = fmt.Errorf("Key already in the tree: %v", op.Key)
+			} else {
+				n = newNode(op.Key, aug)
+			}
+		case OpDelete:
+			if n == nil {
+				errs[i] = fmt.Errorf("Key not found in the tree: %v", op.Key)
+			} else {
+				n = nil
+			}
+		default:
+			errs[i] = fmt.Errorf("Unknown operation kind: %d", op.Kind)
+		}
+	}
+	return n
+}
@@ -0,0 +1,94 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestApplyBatch(t *testing.T) {
+	for iter := 0; iter < 200; iter++ {
+		tree := NewTree()
+		model := map[int]bool{}
+		for _, r := range rand.Perm(rand.Intn(1000)) {
+			tree.Insert(Integer(2 * r))
+			model[2*r] = true
+		}
+
+		// Small batches are merged through split and join, large ones by
+		// rebuilding the tree.
+		n := 1 + rand.Intn(50)
+		if iter%4 == 0 {
+			n = 1 + rand.Intn(2000)
+		}
+		ops := make([]Op, n)
+		for i := range ops {
+			ops[i] = Op{Kind: OpKind(rand.Intn(2)), Key: Integer(rand.Intn(2000))}
+		}
+		sort.SliceStable(ops, func(i, j int) bool { return ops[i].Key.Less(ops[j].Key) })
+
+		errs, err := tree.ApplyBatch(ops)
+		failed := false
+		for i, op := range ops {
+			key := int(op.Key.(Integer))
+			fail := model[key] == (op.Kind == OpInsert)
+			if fail != (errs[i] != nil) {
+				t.Fatalf("op %d (%v %v): error value %v; expected failure: %t\n", i, op.Kind, key, errs[i], fail)
+			}
+			if !fail {
+				model[key] = op.Kind == OpInsert
+			}
+			failed = failed || fail
+		}
+		if failed != (err != nil) {
+			t.Fatalf("joined error value %v; expected failure: %t\n", err, failed)
+		}
+
+		keys := []int{}
+		for key, present := range model {
+			if present {
+				keys = append(keys, key)
+			}
+		}
+		verifyTree(t, tree, keys)
+	}
+}
+
+func TestApplyBatchErrors(t *testing.T) {
+	tree := newIntegerTree(t, 1, 2, 3)
+
+	if errs, err := tree.ApplyBatch([]Op{{OpInsert, Integer(5)}, {OpInsert, Integer(4)}}); err == nil || errs != nil {
+		t.Errorf("\tExpected an error for an unsorted batch!\n")
+	} else {
+		t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+	}
+	verifyTree(t, tree, []int{1, 2, 3})
+
+	errs, err := tree.ApplyBatch([]Op{
+		{OpDelete, Integer(0)},
+		{OpInsert, Integer(2)},
+		{OpDelete, Integer(2)},
+		{OpInsert, Integer(2)},
+		{OpDelete, Integer(4)},
+		{OpKind(7), Integer(5)},
+	})
+	if err == nil {
+		t.Errorf("\tExpected an error!\n")
+	} else {
+		t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+	}
+	for i, fail := range []bool{true, true, false, false, true, true} {
+		if fail != (errs[i] != nil) {
+			t.Errorf("op %d: error value %v; expected failure: %t\n", i, errs[i], fail)
+		}
+	}
+	verifyTree(t, tree, []int{1, 2, 3})
+}
@@ -0,0 +1,147 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import "fmt"
+
+// EvictionPolicy determines what happens when a key is inserted into a full
+// BoundedTree.
+type EvictionPolicy int
+
+const (
+	// EvictMin evicts the minimum key, so that the tree keeps the greatest
+	// keys inserted into it. If the new key is less than all others, it is
+	// the one evicted.
+	EvictMin EvictionPolicy = iota
+	// EvictMax evicts the maximum key, so that the tree keeps the least
+	// keys inserted into it. If the new key is greater than all others, it
+	// is the one evicted.
+	EvictMax
+	// RejectNew fails the insertion of the new key.
+	RejectNew
+)
+
+// BoundedTree is an AVL tree that holds at most a fixed number of keys.
+//
+// The zero value of BoundedTree is an empty tree without a capacity, which
+// never evicts any keys, as if it were a Tree.
+type BoundedTree struct {
+	tree     Tree
+	capacity int
+	policy   EvictionPolicy
+
+	// OnEvict, if not nil, is called with each key evicted from the tree.
+	OnEvict func(Item)
+}
+
+// NewBoundedTree creates a new empty BoundedTree that holds at most capacity
+// keys, evicting them according to policy. It panics if capacity is not
+// positive.
+func NewBoundedTree(capacity int, policy EvictionPolicy) *BoundedTree {
+	if capacity <= 0 {
+		panic(fmt.Sprintf("goavl: non-positive capacity: %d", capacity))
+	}
+	return &BoundedTree{capacity: capacity, policy: policy}
+}
+
+// Capacity returns the maximum number of keys in the BoundedTree, or 0 if it
+// has no capacity.
+func (t *BoundedTree) Capacity() int {
+	return t.capacity
+}
+
+// Size returns the current number of keys in the BoundedTree.
+func (t *BoundedTree) Size() int {
+	return t.tree.Size()
+}
+
+// Insert inserts a key into the BoundedTree. If the tree is full, a key is
+// evicted according to its EvictionPolicy, possibly the inserted one itself,
+// and returned. The error value is non-nil if the key already exists in the
+// tree, or if the tree is full and its EvictionPolicy is RejectNew.
+func (t *BoundedTree) Insert(key Item) (evicted Item, err error) {
+	if t.capacity == 0 || t.tree.Size() < t.capacity {
+		return nil, t.tree.Insert(key)
+	}
+	if t.tree.Contains(key) {
+		return nil, fmt.Errorf("Key already in the tree: %v", key)
+	}
+
+	switch t.policy {
+	case EvictMin:
+		if evicted = t.tree.root.subtreeMin().key; key.Less(evicted) {
+			evicted = key
+		} else {
+			t.tree.PopMin()
+			t.tree.Insert(key)
+		}
+	case EvictMax:
+		if evicted = t.tree.root.subtreeMax().key; evicted.Less(key) {
+			evicted = key
+		} else {
+			t.tree.PopMax()
+			t.tree.Insert(key)
+		}
+	default:
+		return nil, fmt.Errorf("Tree is full: %v", key)
+	}
+	if t.OnEvict != nil {
+		t.OnEvict(evicted)
+	}
+	return evicted, nil
+}
+
+// Delete removes a key from the BoundedTree and returns an error value, which
+// is non-nil if the key doesn't exist in the tree.
+func (t *BoundedTree) Delete(key Item) error {
+	return t.tree.Delete(key)
+}
+
+// Search looks up key in the BoundedTree; see Tree.Search.
+func (t *BoundedTree) Search(key Item) (Item, error) {
+	return t.tree.Search(key)
+}
+
+// Contains reports whether key exists in the BoundedTree.
+func (t *BoundedTree) Contains(key Item) bool {
+	return t.tree.Contains(key)
+}
+
+// Min returns the minimum key in the BoundedTree; see Tree.Min.
+func (t *BoundedTree) Min() (Item, error) {
+	return t.tree.Min()
+}
+
+// Max returns the maximum key in the BoundedTree; see Tree.Max.
+func (t *BoundedTree) Max() (Item, error) {
+	return t.tree.Max()
+}
+
+// PopMin removes the minimum key from the BoundedTree and returns it; see
+// Tree.PopMin.
+func (t *BoundedTree) PopMin() (Item, error) {
+	return t.tree.PopMin()
+}
+
+// PopMax removes the maximum key from the BoundedTree and returns it; see
+// Tree.PopMax.
+func (t *BoundedTree) PopMax() (Item, error) {
+	return t.tree.PopMax()
+}
+
+// InOrder returns a slice of all keys in the BoundedTree, in ascending order.
+func (t *BoundedTree) InOrder() []Item {
+	return t.tree.InOrder()
+}
+
+// AscendRange calls visit for each key in the BoundedTree in [lo, hi); see
+// Tree.AscendRange.
+func (t *BoundedTree) AscendRange(lo, hi Item, visit func(Item) bool) {
+	t.tree.AscendRange(lo, hi, visit)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestBoundedTree(t *testing.T) {
+	for _, policy := range []EvictionPolicy{EvictMin, EvictMax} {
+		tree := NewBoundedTree(10, policy)
+		evictedCallbacks := 0
+		tree.OnEvict = func(Item) { evictedCallbacks++ }
+
+		rands := rand.Perm(1000)
+		evictions := 0
+		for i, r := range rands {
+			evicted, err := tree.Insert(Integer(r))
+			if err != nil {
+				t.Fatalf("\t%v\n", err)
+			}
+			if (i < 10) != (evicted == nil) {
+				t.Fatalf("insertion %d evicted %v\n", i, evicted)
+			}
+			if evicted != nil {
+				evictions++
+			}
+			if size := tree.Size(); size != min(i+1, 10) {
+				t.Fatalf("Size() = %d; expected %d\n", size, min(i+1, 10))
+			}
+		}
+		if evictions != 990 || evictedCallbacks != 990 {
+			t.Errorf("%d evictions and %d callbacks; expected 990\n", evictions, evictedCallbacks)
+		}
+
+		sort.Ints(rands)
+		expected := rands[990:]
+		if policy == EvictMax {
+			expected = rands[:10]
+		}
+		verifyItems(t, tree.InOrder(), expected...)
+		verifyAVL(t, tree.tree.root)
+	}
+}
+
+func TestBoundedTreeEvictNew(t *testing.T) {
+	tree := NewBoundedTree(3, EvictMin)
+	for _, key := range []int{5, 6, 7} {
+		tree.Insert(Integer(key))
+	}
+	if evicted, err := tree.Insert(Integer(1)); err != nil || evicted != Integer(1) {
+		t.Errorf("Insert(1) = %v, %v; expected 1 to be evicted\n", evicted, err)
+	}
+	if evicted, err := tree.Insert(Integer(8)); err != nil || evicted != Integer(5) {
+		t.Errorf("Insert(8) = %v, %v; expected 5 to be evicted\n", evicted, err)
+	}
+	if _, err := tree.Insert(Integer(7)); err == nil {
+		t.Errorf("\tExpected an error for inserting a duplicate key!\n")
+	}
+	verifyItems(t, tree.InOrder(), 6, 7, 8)
+}
+
+func TestBoundedTreeRejectNew(t *testing.T) {
+	tree := NewBoundedTree(2, RejectNew)
+	tree.OnEvict = func(key Item) { t.Errorf("%v evicted\n", key) }
+	tree.Insert(Integer(1))
+	tree.Insert(Integer(2))
+	if evicted, err := tree.Insert(Integer(3)); err == nil || evicted != nil {
+		t.Errorf("\tExpected an error for inserting into a full tree!\n")
+	} else {
+		t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+	}
+	if err := tree.Delete(Integer(1)); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if _, err := tree.Insert(Integer(3)); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	verifyItems(t, tree.InOrder(), 2, 3)
+}
+
+func TestBoundedTreeZeroValue(t *testing.T) {
+	evicted := 0
+	tree := &BoundedTree{OnEvict: func(Item) { evicted++ }}
+	for i := 0; i < 100; i++ {
+		if e, err := tree.Insert(Integer(i)); err != nil || e != nil {
+			t.Fatalf("Insert(%d) = %v, %v; expected no eviction\n", i, e, err)
+		}
+	}
+	if tree.Size() != 100 || tree.Capacity() != 0 || evicted != 0 {
+		t.Errorf("Size(), Capacity() = %d, %d with %d evictions; expected 100, 0, 0\n",
+			tree.Size(), tree.Capacity(), evicted)
+	}
+	if _, err := tree.Insert(Integer(0)); err == nil {
+		t.Errorf("\tExpected an error for inserting a duplicate key!\n")
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+// buildBalanced links the given treeNodes, which must be sorted by their
+// keys, into a perfectly balanced AVL subtree augmented by aug, whose root it
+// returns. It takes O(len(nodes)) time.
+func buildBalanced(nodes []*treeNode, aug Augmenter) *treeNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+	mid := len(nodes) / 2
+	n := nodes[mid]
+	n.left = buildBalanced(nodes[:mid], aug)
+	n.right = buildBalanced(nodes[mid+1:], aug)
+	n.update(aug)
+	return n
+}
+
+// subtreeFilter appends to nodes, in order, the treeNodes of the AVL subtree
+// rooted with n whose keys satisfy keep, and returns the resulting slice.
+func (n *treeNode) subtreeFilter(keep func(Item) bool, nodes []*treeNode) []*treeNode {
+	if n == nil {
+		return nodes
+	}
+	nodes = n.left.subtreeFilter(keep, nodes)
+	if keep(n.key) {
+		nodes = append(nodes, n)
+	}
+	return n.right.subtreeFilter(keep, nodes)
+}
+
+// DeleteFunc removes all keys that satisfy pred from the AVL tree, and returns
+// their number. Instead of deleting the keys one by one, it traverses the tree
+// once and then rebuilds it out of the remaining keys, in O(n) time overall.
+// pred must not modify the tree.
+func (t *Tree) DeleteFunc(pred func(Item) bool) int {
+	return t.RetainFunc(func(key Item) bool { return !pred(key) })
+}
+
+// RetainFunc removes all keys that do not satisfy pred from the AVL tree, and
+// returns their number. Like DeleteFunc, it takes O(n) time. pred must not
+// modify the tree.
+func (t *Tree) RetainFunc(pred func(Item) bool) int {
+	freed := 0
+	keep := func(key Item) bool {
+		if pred(key) {
+			return true
+		}
+		freed += keySize(key)
+		return false
+	}
+	nodes := t.root.subtreeFilter(keep, make([]*treeNode, 0, t.size))
+	removed := t.size - len(nodes)
+	if removed > 0 {
+		t.root = buildBalanced(nodes, t.stats.aug)
+		t.size = len(nodes)
+		t.keyBytes -= freed
+		t.mods++
+	}
+	return removed
+}
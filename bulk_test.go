@@ -0,0 +1,77 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestBuildBalanced(t *testing.T) {
+	for size := 0; size < 100; size++ {
+		nodes := []*treeNode{}
+		keys := []int{}
+		for i := 0; i < size; i++ {
+			nodes = append(nodes, newNode(Integer(i), nil))
+			keys = append(keys, i)
+		}
+		tree := &Tree{root: buildBalanced(nodes, nil), size: size}
+		verifyTree(t, tree, keys)
+		if bound := maxHeight(size); tree.Height() > bound {
+			t.Errorf("height of %d keys is %d; expected at most %d\n", size, tree.Height(), bound)
+		}
+	}
+}
+
+func TestDeleteRetainFunc(t *testing.T) {
+	tree := NewTree()
+	keys := rand.Perm(1 << 12)
+	for _, key := range keys {
+		if err := tree.Insert(Integer(key)); err != nil {
+			t.Errorf("\t%v\n", err)
+		}
+	}
+
+	even := func(key Item) bool { return key.(Integer)%2 == 0 }
+	if n := tree.DeleteFunc(even); n != 1<<11 {
+		t.Errorf("tree.DeleteFunc(even) = %d; expected %d\n", n, 1<<11)
+	}
+	odd := []int{}
+	for _, key := range keys {
+		if key%2 != 0 {
+			odd = append(odd, key)
+		}
+	}
+	verifyTree(t, tree, odd)
+
+	if n := tree.DeleteFunc(even); n != 0 {
+		t.Errorf("tree.DeleteFunc(even) = %d; expected 0\n", n)
+	}
+
+	small := func(key Item) bool { return key.(Integer) < 100 }
+	if n := tree.RetainFunc(small); n != len(odd)-50 {
+		t.Errorf("tree.RetainFunc(small) = %d; expected %d\n", n, len(odd)-50)
+	}
+	kept := []int{}
+	for key := 1; key < 100; key += 2 {
+		kept = append(kept, key)
+	}
+	verifyTree(t, tree, kept)
+
+	// The tree keeps working after being rebuilt.
+	if err := tree.Insert(Integer(0)); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	verifyTree(t, tree, append(kept, 0))
+
+	if n := tree.RetainFunc(func(Item) bool { return false }); n != 51 {
+		t.Errorf("tree.RetainFunc(none) = %d; expected 51\n", n)
+	}
+	verifyTree(t, tree, nil)
+}
@@ -0,0 +1,212 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+// Command avlbench runs configurable workloads against goavl.Tree and other
+// ordered structures, and reports their throughput, latency percentiles,
+// allocations and, where applicable, final height.
+//
+// Usage:
+//
+//	avlbench [flags]
+//
+// For example, to compare all structures on a zipfian workload of 100000 keys
+// and print the results as JSON:
+//
+//	avlbench -workload zipfian -n 100000 -format json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// latency holds the latency percentiles of the operations of a single kind.
+type latency struct {
+	Count int           `json:"count"`
+	P50   time.Duration `json:"p50_ns"`
+	P90   time.Duration `json:"p90_ns"`
+	P99   time.Duration `json:"p99_ns"`
+	Max   time.Duration `json:"max_ns"`
+}
+
+// result holds the measurements of running a workload against a structure.
+type result struct {
+	Workload   string              `json:"workload"`
+	Structure  string              `json:"structure"`
+	Ops        int                 `json:"ops"`
+	Elapsed    time.Duration       `json:"elapsed_ns"`
+	Throughput float64             `json:"ops_per_sec"`
+	Latencies  map[string]*latency `json:"latencies"`
+	Allocs     uint64              `json:"allocs"`
+	AllocBytes uint64              `json:"alloc_bytes"`
+	Height     int                 `json:"height,omitempty"`
+}
+
+// run runs ops against s and returns its measurements.
+func run(s structure, ops []op) *result {
+	samples := make([][]time.Duration, numOpKinds)
+	for i := range samples {
+		samples[i] = make([]time.Duration, 0, len(ops))
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for _, o := range ops {
+		t0 := time.Now()
+		switch o.kind {
+		case opInsert:
+			s.insert(o.key)
+		case opDelete:
+			s.delete(o.key)
+		case opLookup:
+			s.lookup(o.key)
+		case opScan:
+			s.scan(o.key, o.hi)
+		}
+		samples[o.kind] = append(samples[o.kind], time.Since(t0))
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	res := &result{
+		Ops:        len(ops),
+		Elapsed:    elapsed,
+		Throughput: float64(len(ops)) / elapsed.Seconds(),
+		Latencies:  make(map[string]*latency),
+		Allocs:     after.Mallocs - before.Mallocs,
+		AllocBytes: after.TotalAlloc - before.TotalAlloc,
+	}
+	if h := s.height(); h >= 0 {
+		res.Height = h
+	}
+	for kind, durations := range samples {
+		if len(durations) > 0 {
+			res.Latencies[opKind(kind).String()] = percentiles(durations)
+		}
+	}
+	return res
+}
+
+// percentiles sorts durations and returns their percentiles.
+func percentiles(durations []time.Duration) *latency {
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	at := func(p float64) time.Duration {
+		return durations[int(p*float64(len(durations)-1))]
+	}
+	return &latency{
+		Count: len(durations),
+		P50:   at(.50),
+		P90:   at(.90),
+		P99:   at(.99),
+		Max:   durations[len(durations)-1],
+	}
+}
+
+// writeTable writes results to w as a human-readable table.
+func writeTable(w io.Writer, results []*result) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "workload\tstructure\top\tcount\tp50\tp90\tp99\tmax\tops/s\tallocs\tbytes\theight\t")
+	for _, res := range results {
+		height := "-"
+		if res.Height > 0 {
+			height = fmt.Sprint(res.Height)
+		}
+		first := true
+		for kind := opKind(0); kind < numOpKinds; kind++ {
+			l, ok := res.Latencies[kind.String()]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%v\t%v\t%v\t%v\t", res.Workload, res.Structure,
+				kind, l.Count, l.P50, l.P90, l.P99, l.Max)
+			if first {
+				fmt.Fprintf(tw, "%.0f\t%d\t%d\t%s\t\n", res.Throughput, res.Allocs, res.AllocBytes, height)
+				first = false
+			} else {
+				fmt.Fprint(tw, "\t\t\t\t\n")
+			}
+		}
+	}
+	return tw.Flush()
+}
+
+func main() {
+	var (
+		cfg       config
+		workload  = flag.String("workload", "random", "workload to run: "+strings.Join(workloadNames(), ", ")+", or all")
+		structs   = flag.String("structures", strings.Join(structureNames(), ","), "comma-separated structures to run the workload against")
+		format    = flag.String("format", "table", "output format: table or json")
+		rounds    = flag.Int("rounds", 1, "number of times to run each workload against each structure")
+		benchmark []string
+	)
+	flag.IntVar(&cfg.n, "n", 100000, "number of keys")
+	flag.IntVar(&cfg.window, "window", 1000, "window size of the sliding-window workload")
+	flag.IntVar(&cfg.scan, "scan", 100, "width of the ranges of the scans")
+	flag.Float64Var(&cfg.zipfS, "zipf", 1.1, "exponent (> 1) of the zipfian distribution")
+	flag.Int64Var(&cfg.seed, "seed", 1, "seed of the workload generator")
+	flag.Parse()
+
+	if cfg.n < 2 || cfg.window < 1 || cfg.scan < 1 || cfg.zipfS <= 1 || *rounds < 1 {
+		fmt.Fprintln(os.Stderr, "avlbench: invalid workload parameters")
+		os.Exit(2)
+	}
+	if *workload == "all" {
+		benchmark = workloadNames()
+	} else if _, ok := workloads[*workload]; ok {
+		benchmark = []string{*workload}
+	} else {
+		fmt.Fprintf(os.Stderr, "avlbench: unknown workload %q\n", *workload)
+		os.Exit(2)
+	}
+	names := strings.Split(*structs, ",")
+	for _, name := range names {
+		if _, ok := structures[name]; !ok {
+			fmt.Fprintf(os.Stderr, "avlbench: unknown structure %q\n", name)
+			os.Exit(2)
+		}
+	}
+
+	var results []*result
+	for _, w := range benchmark {
+		ops := workloads[w](cfg, rand.New(rand.NewSource(cfg.seed)))
+		for _, name := range names {
+			for i := 0; i < *rounds; i++ {
+				res := run(structures[name](), ops)
+				res.Workload, res.Structure = w, name
+				results = append(results, res)
+			}
+		}
+	}
+
+	var err error
+	switch *format {
+	case "table":
+		err = writeTable(os.Stdout, results)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(results)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "avlbench: %v\n", err)
+		os.Exit(1)
+	}
+}
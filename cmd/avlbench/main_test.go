@@ -0,0 +1,83 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+var testConfig = config{n: 1000, window: 50, scan: 10, zipfS: 1.1, seed: 42}
+
+// TestStructuresAgree checks that all structures produce the same results
+// for every operation of every workload.
+func TestStructuresAgree(t *testing.T) {
+	for _, w := range workloadNames() {
+		ops := workloads[w](testConfig, rand.New(rand.NewSource(testConfig.seed)))
+		var expected []int
+		for _, name := range structureNames() {
+			s := structures[name]()
+			got := make([]int, len(ops))
+			for i, o := range ops {
+				var ok bool
+				switch o.kind {
+				case opInsert:
+					ok = s.insert(o.key)
+				case opDelete:
+					ok = s.delete(o.key)
+				case opLookup:
+					ok = s.lookup(o.key)
+				case opScan:
+					got[i] = s.scan(o.key, o.hi)
+					continue
+				}
+				if ok {
+					got[i] = 1
+				}
+			}
+			if expected == nil {
+				expected = got
+				continue
+			}
+			for i := range got {
+				if got[i] != expected[i] {
+					t.Errorf("%s: %s: op %d (%s %d) returned %d; expected %d\n",
+						w, name, i, ops[i].kind, ops[i].key, got[i], expected[i])
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestRunAndReport(t *testing.T) {
+	ops := sequentialWorkload(testConfig, rand.New(rand.NewSource(testConfig.seed)))
+	res := run(newAVL(), ops)
+	res.Workload, res.Structure = "sequential", "avl"
+
+	if res.Ops != len(ops) {
+		t.Errorf("res.Ops = %d; expected %d\n", res.Ops, len(ops))
+	}
+	if res.Height != 10 { // 1000 ascending keys
+		t.Errorf("res.Height = %d; expected 10\n", res.Height)
+	}
+	if l := res.Latencies["insert"]; l == nil || l.Count != testConfig.n {
+		t.Errorf("res.Latencies[\"insert\"] = %+v; expected %d samples\n", l, testConfig.n)
+	}
+
+	var b bytes.Buffer
+	if err := writeTable(&b, []*result{res}); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	if lines := strings.Count(b.String(), "\n"); lines != 4 { // header + 3 op kinds
+		t.Errorf("table has %d lines; expected 4:\n%s\n", lines, b.String())
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package main
+
+import (
+	"sort"
+
+	"github.com/ckatsak/goavl"
+)
+
+// structure is an ordered set of ints that workloads can be run against.
+type structure interface {
+	// insert inserts key and reports whether it was not already present.
+	insert(key int) bool
+	// delete deletes key and reports whether it was present.
+	delete(key int) bool
+	// lookup reports whether key is present.
+	lookup(key int) bool
+	// scan returns the number of keys in [lo, hi), visiting them in order.
+	scan(lo, hi int) int
+	// height returns the height of the structure, or -1 if not applicable.
+	height() int
+}
+
+// structures maps the names of the available structures to their
+// constructors.
+var structures = map[string]func() structure{
+	"avl":      newAVL,
+	"map+sort": newMapSort,
+	"slice":    newSortedSlice,
+}
+
+// structureNames returns the names of the available structures, sorted.
+func structureNames() []string {
+	names := make([]string, 0, len(structures))
+	for name := range structures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// avl is a structure backed by goavl.Tree.
+type avl struct {
+	tree *goavl.Tree
+}
+
+func newAVL() structure {
+	return &avl{tree: goavl.NewTree()}
+}
+
+func (s *avl) insert(k int) bool {
+	return s.tree.Insert(goavl.Int(k)) == nil
+}
+
+func (s *avl) delete(k int) bool {
+	return s.tree.Delete(goavl.Int(k)) == nil
+}
+
+func (s *avl) lookup(k int) bool {
+	return s.tree.Contains(goavl.Int(k))
+}
+
+func (s *avl) scan(lo, hi int) int {
+	count := 0
+	s.tree.AscendRange(goavl.Int(lo), goavl.Int(hi), func(goavl.Item) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+func (s *avl) height() int {
+	return s.tree.Height()
+}
+
+// mapSort is a structure backed by a map, whose keys are sorted into a slice
+// lazily, upon the first scan after any modification.
+type mapSort struct {
+	keys   map[int]struct{}
+	sorted []int
+	dirty  bool
+}
+
+func newMapSort() structure {
+	return &mapSort{keys: make(map[int]struct{})}
+}
+
+func (s *mapSort) insert(k int) bool {
+	if _, ok := s.keys[k]; ok {
+		return false
+	}
+	s.keys[k] = struct{}{}
+	s.dirty = true
+	return true
+}
+
+func (s *mapSort) delete(k int) bool {
+	if _, ok := s.keys[k]; !ok {
+		return false
+	}
+	delete(s.keys, k)
+	s.dirty = true
+	return true
+}
+
+func (s *mapSort) lookup(k int) bool {
+	_, ok := s.keys[k]
+	return ok
+}
+
+func (s *mapSort) scan(lo, hi int) int {
+	if s.dirty {
+		s.sorted = s.sorted[:0]
+		for k := range s.keys {
+			s.sorted = append(s.sorted, k)
+		}
+		sort.Ints(s.sorted)
+		s.dirty = false
+	}
+	return scanSorted(s.sorted, lo, hi)
+}
+
+func (s *mapSort) height() int {
+	return -1
+}
+
+// sortedSlice is a structure backed by a sorted slice.
+type sortedSlice struct {
+	keys []int
+}
+
+func newSortedSlice() structure {
+	return &sortedSlice{}
+}
+
+func (s *sortedSlice) insert(k int) bool {
+	i := sort.SearchInts(s.keys, k)
+	if i < len(s.keys) && s.keys[i] == k {
+		return false
+	}
+	s.keys = append(s.keys, 0)
+	copy(s.keys[i+1:], s.keys[i:])
+	s.keys[i] = k
+	return true
+}
+
+func (s *sortedSlice) delete(k int) bool {
+	i := sort.SearchInts(s.keys, k)
+	if i == len(s.keys) || s.keys[i] != k {
+		return false
+	}
+	s.keys = append(s.keys[:i], s.keys[i+1:]...)
+	return true
+}
+
+func (s *sortedSlice) lookup(k int) bool {
+	i := sort.SearchInts(s.keys, k)
+	return i < len(s.keys) && s.keys[i] == k
+}
+
+func (s *sortedSlice) scan(lo, hi int) int {
+	return scanSorted(s.keys, lo, hi)
+}
+
+func (s *sortedSlice) height() int {
+	return -1
+}
+
+// sink keeps the compiler from optimizing scans away.
+var sink int
+
+// scanSorted visits the keys of the sorted slice keys that lie in [lo, hi),
+// and returns their number.
+func scanSorted(keys []int, lo, hi int) int {
+	count := 0
+	for i := sort.SearchInts(keys, lo); i < len(keys) && keys[i] < hi; i++ {
+		sink += keys[i]
+		count++
+	}
+	return count
+}
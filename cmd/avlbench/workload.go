@@ -0,0 +1,166 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// opKind is the kind of an operation of a workload.
+type opKind int
+
+const (
+	opInsert opKind = iota
+	opDelete
+	opLookup
+	opScan
+	numOpKinds
+)
+
+func (k opKind) String() string {
+	switch k {
+	case opInsert:
+		return "insert"
+	case opDelete:
+		return "delete"
+	case opLookup:
+		return "lookup"
+	case opScan:
+		return "scan"
+	}
+	return fmt.Sprintf("opKind(%d)", int(k))
+}
+
+// op is a single operation of a workload. For scans, the range [key, hi) is
+// scanned.
+type op struct {
+	kind    opKind
+	key, hi int
+}
+
+// config holds the parameters of workload generation.
+type config struct {
+	n      int     // number of keys
+	window int     // size of the window of the sliding-window workload
+	scan   int     // width of the ranges of the scans
+	zipfS  float64 // exponent of the zipfian distribution
+	seed   int64
+}
+
+// workloads maps the names of the available workloads to their generators.
+var workloads = map[string]func(cfg config, r *rand.Rand) []op{
+	"sequential":     sequentialWorkload,
+	"random":         randomWorkload,
+	"zipfian":        zipfianWorkload,
+	"sliding-window": slidingWindowWorkload,
+	"delete-heavy":   deleteHeavyWorkload,
+}
+
+// workloadNames returns the names of the available workloads, sorted.
+func workloadNames() []string {
+	names := make([]string, 0, len(workloads))
+	for name := range workloads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sequentialWorkload inserts keys in ascending order, then looks all of them
+// up and scans the whole key space in consecutive ranges.
+func sequentialWorkload(cfg config, r *rand.Rand) []op {
+	ops := make([]op, 0, 2*cfg.n+cfg.n/cfg.scan+1)
+	for i := 0; i < cfg.n; i++ {
+		ops = append(ops, op{kind: opInsert, key: i})
+	}
+	for i := 0; i < cfg.n; i++ {
+		ops = append(ops, op{kind: opLookup, key: i})
+	}
+	for i := 0; i < cfg.n; i += cfg.scan {
+		ops = append(ops, op{kind: opScan, key: i, hi: i + cfg.scan})
+	}
+	return ops
+}
+
+// randomWorkload inserts uniformly random keys, then looks up uniformly
+// random keys (about half of which are present) and scans random ranges.
+func randomWorkload(cfg config, r *rand.Rand) []op {
+	space := 2 * cfg.n
+	ops := make([]op, 0, 2*cfg.n+cfg.n/cfg.scan+1)
+	for i := 0; i < cfg.n; i++ {
+		ops = append(ops, op{kind: opInsert, key: r.Intn(space)})
+	}
+	for i := 0; i < cfg.n; i++ {
+		ops = append(ops, op{kind: opLookup, key: r.Intn(space)})
+	}
+	for i := 0; i < cfg.n/cfg.scan+1; i++ {
+		lo := r.Intn(space)
+		ops = append(ops, op{kind: opScan, key: lo, hi: lo + 2*cfg.scan})
+	}
+	return ops
+}
+
+// zipfianWorkload inserts random keys, then looks up keys that follow a
+// zipfian distribution, so that a few of them are very popular.
+func zipfianWorkload(cfg config, r *rand.Rand) []op {
+	keys := r.Perm(cfg.n)
+	zipf := rand.NewZipf(r, cfg.zipfS, 1, uint64(cfg.n-1))
+	ops := make([]op, 0, 2*cfg.n+cfg.n/cfg.scan+1)
+	for _, key := range keys {
+		ops = append(ops, op{kind: opInsert, key: key})
+	}
+	for i := 0; i < cfg.n; i++ {
+		ops = append(ops, op{kind: opLookup, key: keys[zipf.Uint64()]})
+	}
+	for i := 0; i < cfg.n/cfg.scan+1; i++ {
+		lo := keys[zipf.Uint64()]
+		ops = append(ops, op{kind: opScan, key: lo, hi: lo + cfg.scan})
+	}
+	return ops
+}
+
+// slidingWindowWorkload inserts keys in ascending order, while deleting the
+// oldest key once window keys are present; each step also looks up a random
+// key of the window, and every window/2 steps the whole window is scanned.
+func slidingWindowWorkload(cfg config, r *rand.Rand) []op {
+	ops := make([]op, 0, 3*cfg.n)
+	for i := 0; i < cfg.n; i++ {
+		ops = append(ops, op{kind: opInsert, key: i})
+		oldest := i - cfg.window + 1
+		if oldest > 0 {
+			ops = append(ops, op{kind: opDelete, key: oldest - 1})
+		} else {
+			oldest = 0
+		}
+		ops = append(ops, op{kind: opLookup, key: oldest + r.Intn(i-oldest+1)})
+		if i%(cfg.window/2+1) == 0 {
+			ops = append(ops, op{kind: opScan, key: oldest, hi: i + 1})
+		}
+	}
+	return ops
+}
+
+// deleteHeavyWorkload inserts random keys and then deletes most of them in
+// random order, interleaving a lookup of a random key after each deletion.
+func deleteHeavyWorkload(cfg config, r *rand.Rand) []op {
+	keys := r.Perm(cfg.n)
+	ops := make([]op, 0, cfg.n+2*(cfg.n*9/10)+1)
+	for _, key := range keys {
+		ops = append(ops, op{kind: opInsert, key: key})
+	}
+	r.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	for _, key := range keys[:cfg.n*9/10] {
+		ops = append(ops, op{kind: opDelete, key: key})
+		ops = append(ops, op{kind: opLookup, key: r.Intn(cfg.n)})
+	}
+	ops = append(ops, op{kind: opScan, key: 0, hi: cfg.n})
+	return ops
+}
@@ -0,0 +1,66 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+// Command goavl holds an AVL tree of integers or strings and manipulates it
+// through simple commands, read either interactively or from script files.
+// The shape of the tree is printed after each modification.
+//
+// Usage:
+//
+//	goavl [-type int|string] [-depth N] [SCRIPT...]
+//
+// Without any SCRIPT, commands are read from the standard input. Type "help"
+// for the list of the supported commands.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ckatsak/goavl"
+)
+
+func main() {
+	keyType := flag.String("type", "int", "type of the keys: int or string")
+	maxDepth := flag.Int("depth", 0, "maximum depth of the printed tree shapes (0 for unlimited)")
+	flag.Parse()
+
+	parse, ok := parsers[*keyType]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "goavl: unknown key type %q\n", *keyType)
+		os.Exit(2)
+	}
+	r := &repl{
+		tree:     goavl.NewTree(),
+		parse:    parse,
+		out:      os.Stdout,
+		maxDepth: *maxDepth,
+	}
+
+	failed := 0
+	if flag.NArg() == 0 {
+		prompt := ""
+		if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+			prompt = "goavl> "
+		}
+		failed = r.run(os.Stdin, os.Stderr, "<stdin>", prompt)
+	}
+	for _, path := range flag.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "goavl: %v\n", err)
+			os.Exit(1)
+		}
+		failed += r.run(f, os.Stderr, path, "")
+		f.Close()
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,224 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ckatsak/goavl"
+	"github.com/ckatsak/goavl/debug"
+)
+
+// parsers maps the supported key types to the functions that parse them.
+var parsers = map[string]func(string) (goavl.Item, error){
+	"int": func(s string) (goavl.Item, error) {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid integer: %q", s)
+		}
+		return goavl.Int(i), nil
+	},
+	"string": func(s string) (goavl.Item, error) {
+		return goavl.String(s), nil
+	},
+}
+
+// errQuit is returned by execute when the session should end.
+var errQuit = errors.New("quit")
+
+const help = `Commands:
+  insert KEY...   insert keys into the tree
+  delete KEY...   delete keys from the tree
+  min, max        print the minimum or maximum key
+  inorder         print the keys in in-order
+  preorder        print the keys in pre-order
+  height, size    print the height or the size of the tree
+  show            print the shape of the tree
+  load FILE       insert the whitespace-separated keys found in FILE
+  save FILE       write the keys of the tree to FILE, one per line
+  help            print this message
+  quit, exit      end the session
+`
+
+// repl holds the state of a session.
+type repl struct {
+	tree     *goavl.Tree
+	parse    func(string) (goavl.Item, error)
+	out      io.Writer
+	maxDepth int
+}
+
+// execute executes the command found in line. Errors of individual keys do
+// not stop the execution of the command; they are all reported at its end.
+func (r *repl) execute(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+		return nil
+	}
+	cmd, args := fields[0], fields[1:]
+
+	switch cmd {
+	case "insert", "delete":
+		if len(args) == 0 {
+			return fmt.Errorf("Usage: %s KEY...", cmd)
+		}
+		op := r.tree.Insert
+		if cmd == "delete" {
+			op = r.tree.Delete
+		}
+		var errs []error
+		for _, arg := range args {
+			key, err := r.parse(arg)
+			if err == nil {
+				err = op(key)
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		r.show()
+		return errors.Join(errs...)
+	case "load":
+		if len(args) != 1 {
+			return fmt.Errorf("Usage: load FILE")
+		}
+		err := r.load(args[0])
+		r.show()
+		return err
+	case "save":
+		if len(args) != 1 {
+			return fmt.Errorf("Usage: save FILE")
+		}
+		return r.save(args[0])
+	case "help":
+		fmt.Fprint(r.out, help)
+		return nil
+	case "quit", "exit":
+		return errQuit
+	}
+
+	if len(args) != 0 {
+		return fmt.Errorf("Usage: %s", cmd)
+	}
+	switch cmd {
+	case "min", "max":
+		get := r.tree.Min
+		if cmd == "max" {
+			get = r.tree.Max
+		}
+		key, err := get()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, key)
+	case "inorder":
+		fmt.Fprintln(r.out, r.tree.InOrder())
+	case "preorder":
+		fmt.Fprintln(r.out, r.tree.PreOrder())
+	case "height":
+		fmt.Fprintln(r.out, r.tree.Height())
+	case "size":
+		fmt.Fprintln(r.out, r.tree.Size())
+	case "show":
+		r.show()
+	default:
+		return fmt.Errorf("Unknown command: %q (try \"help\")", cmd)
+	}
+	return nil
+}
+
+// show prints the shape of the tree.
+func (r *repl) show() {
+	debug.WriteShape(r.out, r.tree, r.maxDepth)
+}
+
+// load inserts into the tree the whitespace-separated keys found in the file
+// at path.
+func (r *repl) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var errs []error
+	s := bufio.NewScanner(f)
+	s.Split(bufio.ScanWords)
+	for s.Scan() {
+		key, err := r.parse(s.Text())
+		if err == nil {
+			err = r.tree.Insert(key)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := s.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// save writes the keys of the tree to the file at path, one per line, in
+// ascending order.
+func (r *repl) save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, key := range r.tree.InOrder() {
+		fmt.Fprintln(w, key)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// run executes the commands read from in, one per line, reporting errors to
+// errOut. If prompt is non-empty, it is printed before reading each line. It
+// returns the number of commands that failed.
+func (r *repl) run(in io.Reader, errOut io.Writer, name, prompt string) int {
+	failed := 0
+	s := bufio.NewScanner(in)
+	for lineno := 1; ; lineno++ {
+		fmt.Fprint(r.out, prompt)
+		if !s.Scan() {
+			break
+		}
+		err := r.execute(s.Text())
+		if err == errQuit {
+			break
+		}
+		if err != nil {
+			failed++
+			if prompt != "" {
+				fmt.Fprintf(errOut, "error: %v\n", err)
+			} else {
+				fmt.Fprintf(errOut, "%s:%d: %v\n", name, lineno, err)
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		failed++
+		fmt.Fprintf(errOut, "%s: %v\n", name, err)
+	}
+	if prompt != "" {
+		fmt.Fprintln(r.out)
+	}
+	return failed
+}
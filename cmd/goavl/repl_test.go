@@ -0,0 +1,94 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ckatsak/goavl"
+)
+
+func newRepl(keyType string) (*repl, *bytes.Buffer) {
+	var out bytes.Buffer
+	return &repl{tree: goavl.NewTree(), parse: parsers[keyType], out: &out}, &out
+}
+
+func TestScript(t *testing.T) {
+	r, out := newRepl("int")
+	script := `# a comment
+insert 2 1 3
+insert 3
+delete 42
+min
+max
+inorder
+size
+quit
+insert 4
+`
+	var errOut bytes.Buffer
+	if failed := r.run(strings.NewReader(script), &errOut, "script", ""); failed != 2 {
+		t.Errorf("run() = %d; expected 2 failed commands\n", failed)
+	}
+	for _, s := range []string{"script:3: Key already in the tree: 3", "script:4: Key not found in the tree: 42"} {
+		if !strings.Contains(errOut.String(), s) {
+			t.Errorf("errors do not contain %q:\n%s\n", s, errOut.String())
+		}
+	}
+	for _, s := range []string{"2 (h=2)\n├── 1 (h=1)\n└── 3 (h=1)\n", "1\n3\n[1 2 3]\n3\n"} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("output does not contain %q:\n%s\n", s, out.String())
+		}
+	}
+	if r.tree.Size() != 3 {
+		t.Errorf("tree.Size() = %d; expected 3 (commands after quit were run)\n", r.tree.Size())
+	}
+}
+
+func TestCommandErrors(t *testing.T) {
+	r, _ := newRepl("int")
+	for _, line := range []string{"min", "insert", "insert foo", "height 1", "frobnicate", "load"} {
+		if err := r.execute(line); err == nil {
+			t.Errorf("execute(%q): expected an error!\n", line)
+		} else {
+			t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+		}
+	}
+}
+
+func TestLoadSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "keys")
+	if err := os.WriteFile(path, []byte("pear apple\nfig\n"), 0644); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+
+	r, _ := newRepl("string")
+	if err := r.execute("load " + path); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	if err := r.execute("load " + path); err == nil {
+		t.Errorf("\tExpected an error for reloading the same keys!\n")
+	}
+	saved := filepath.Join(dir, "saved")
+	if err := r.execute("save " + saved); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	b, err := os.ReadFile(saved)
+	if err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	if expected := "apple\nfig\npear\n"; string(b) != expected {
+		t.Errorf("saved %q; expected %q\n", b, expected)
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// KeyCodec encodes Items into byte slices and decodes them back, so that keys
+// can be stored outside of memory. Decode must return an Item equal to the
+// one that was encoded.
+type KeyCodec interface {
+	Encode(key Item) ([]byte, error)
+	Decode(b []byte) (Item, error)
+}
+
+// KeyCodecs of some of the Item types of this package.
+var (
+	IntCodec    KeyCodec = intCodec{}
+	StringCodec KeyCodec = stringCodec{}
+	BytesCodec  KeyCodec = bytesCodec{}
+)
+
+// intCodec encodes Ints as 8 bytes, in big-endian order.
+type intCodec struct{}
+
+func (intCodec) Encode(key Item) ([]byte, error) {
+	i, ok := key.(Int)
+	if !ok {
+		return nil, fmt.Errorf("Unexpected type of key: %T", key)
+	}
+	return binary.BigEndian.AppendUint64(nil, uint64(i)), nil
+}
+
+func (intCodec) Decode(b []byte) (Item, error) {
+	if len(b) != 8 {
+		return nil, fmt.Errorf("Invalid encoded Int of %d bytes", len(b))
+	}
+	return Int(int64(binary.BigEndian.Uint64(b))), nil
+}
+
+// stringCodec encodes Strings as their bytes.
+type stringCodec struct{}
+
+func (stringCodec) Encode(key Item) ([]byte, error) {
+	s, ok := key.(String)
+	if !ok {
+		return nil, fmt.Errorf("Unexpected type of key: %T", key)
+	}
+	return []byte(s), nil
+}
+
+func (stringCodec) Decode(b []byte) (Item, error) {
+	return String(b), nil
+}
+
+// bytesCodec encodes Bytes as themselves.
+type bytesCodec struct{}
+
+func (bytesCodec) Encode(key Item) ([]byte, error) {
+	b, ok := key.(Bytes)
+	if !ok {
+		return nil, fmt.Errorf("Unexpected type of key: %T", key)
+	}
+	return append([]byte{}, b...), nil
+}
+
+func (bytesCodec) Decode(b []byte) (Item, error) {
+	return Bytes(append([]byte{}, b...)), nil
+}
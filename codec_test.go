@@ -0,0 +1,44 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import "testing"
+
+func TestKeyCodecs(t *testing.T) {
+	for _, tc := range []struct {
+		codec KeyCodec
+		keys  []Item
+		wrong Item
+	}{
+		{IntCodec, []Item{Int(0), Int(-1), Int(1 << 40), Int(-1 << 62)}, String("1")},
+		{StringCodec, []Item{String(""), String("abc"), String("\x00\xff")}, Int(1)},
+		{BytesCodec, []Item{Bytes{}, Bytes("abc"), Bytes{0, 0xff}}, String("abc")},
+	} {
+		for _, key := range tc.keys {
+			b, err := tc.codec.Encode(key)
+			if err != nil {
+				t.Errorf("\t%v\n", err)
+				continue
+			}
+			decoded, err := tc.codec.Decode(b)
+			if err != nil || !decoded.Equal(key) {
+				t.Errorf("Decode(Encode(%v)) = %v, %v\n", key, decoded, err)
+			}
+		}
+		if _, err := tc.codec.Encode(tc.wrong); err == nil {
+			t.Errorf("\tExpected an error for encoding %T with %T!\n", tc.wrong, tc.codec)
+		} else {
+			t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+		}
+	}
+
+	if _, err := IntCodec.Decode([]byte{1, 2, 3}); err == nil {
+		t.Errorf("\tExpected an error for decoding a truncated Int!\n")
+	}
+}
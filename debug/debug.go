@@ -0,0 +1,251 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+// Package debug provides live inspection of goavl trees, by publishing their
+// statistics via expvar and by serving their contents over net/http.
+//
+// goavl trees are not safe for concurrent use; if the inspected tree is being
+// modified by other goroutines, a sync.Locker that guards it should be handed
+// to this package, so that it can be locked while the tree is being read.
+package debug
+
+import (
+	"expvar"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ckatsak/goavl"
+)
+
+// Publish publishes the size, the height and the statistics of t as an
+// expvar.Var under the given name. If mu is non-nil, it is locked while t is
+// being read. Like expvar.Publish, it panics if name is already registered.
+func Publish(name string, t *goavl.Tree, mu sync.Locker) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		if mu != nil {
+			mu.Lock()
+			defer mu.Unlock()
+		}
+		return map[string]interface{}{
+			"size":   t.Size(),
+			"height": t.Height(),
+			"stats":  t.Stats(),
+		}
+	}))
+}
+
+// Default values for the configuration of a Handler.
+const (
+	DefaultPageSize = 50
+	DefaultMaxDepth = 8
+)
+
+// Handler is an http.Handler that serves an HTML page describing a goavl
+// tree: its size, height and statistics, the shape of its upper levels, a
+// paginated listing of its keys, and a key lookup form.
+//
+// The page accepts the following query parameters:
+//
+//	lo, hi  bounds of the range [lo, hi) of keys to be listed
+//	page    0-based page of the listed range
+//	key     key to be looked up
+//
+// Keys are parsed by ParseKey; if it is nil, range bounds and lookups are not
+// supported.
+type Handler struct {
+	// Tree is the tree to be inspected.
+	Tree *goavl.Tree
+
+	// Lock, if non-nil, is locked while Tree is being read.
+	Lock sync.Locker
+
+	// ParseKey parses the textual form of a key, as given in a request.
+	ParseKey func(string) (goavl.Item, error)
+
+	// PageSize is the number of keys listed per page; DefaultPageSize if 0.
+	PageSize int
+
+	// MaxDepth is the number of levels of the tree whose shape is rendered;
+	// DefaultMaxDepth if 0, or all of them if negative.
+	MaxDepth int
+}
+
+// page holds the data rendered by pageTemplate.
+type page struct {
+	Size, Height int
+	Stats        goavl.Stats
+	Shape        string
+	Lo, Hi       string
+	Page         int
+	First        int
+	Keys         []goavl.Item
+	PrevPage     string
+	NextPage     string
+	Key          string
+	Lookup       string
+	Errors       []string
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	p := page{
+		Lo:  q.Get("lo"),
+		Hi:  q.Get("hi"),
+		Key: q.Get("key"),
+	}
+	if s := q.Get("page"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, fmt.Sprintf("Invalid page: %q", s), http.StatusBadRequest)
+			return
+		}
+		p.Page = n
+	}
+	lo, err := h.parseKey(p.Lo)
+	if err != nil {
+		p.Errors = append(p.Errors, fmt.Sprintf("lo: %v", err))
+	}
+	hi, err := h.parseKey(p.Hi)
+	if err != nil {
+		p.Errors = append(p.Errors, fmt.Sprintf("hi: %v", err))
+	}
+	key, err := h.parseKey(p.Key)
+	if err != nil {
+		p.Errors = append(p.Errors, fmt.Sprintf("key: %v", err))
+	}
+
+	if h.Lock != nil {
+		h.Lock.Lock()
+	}
+	h.read(&p, lo, hi, key)
+	if h.Lock != nil {
+		h.Lock.Unlock()
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pageTemplate.Execute(w, &p); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// parseKey parses s via h.ParseKey, unless s is empty.
+func (h *Handler) parseKey(s string) (goavl.Item, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if h.ParseKey == nil {
+		return nil, fmt.Errorf("Parsing keys is not supported")
+	}
+	return h.ParseKey(s)
+}
+
+// read fills p in with the data read from h.Tree.
+func (h *Handler) read(p *page, lo, hi, key goavl.Item) {
+	p.Size = h.Tree.Size()
+	p.Height = h.Tree.Height()
+	p.Stats = h.Tree.Stats()
+
+	maxDepth := h.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	var shape strings.Builder
+	if err := WriteShape(&shape, h.Tree, maxDepth); err != nil {
+		p.Errors = append(p.Errors, err.Error())
+	}
+	p.Shape = shape.String()
+
+	pageSize := h.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	p.First = p.Page*pageSize + 1
+	skip, more := p.Page*pageSize, false
+	h.Tree.AscendRange(lo, hi, func(item goavl.Item) bool {
+		if skip > 0 {
+			skip--
+			return true
+		}
+		if len(p.Keys) == pageSize {
+			more = true
+			return false
+		}
+		p.Keys = append(p.Keys, item)
+		return true
+	})
+	if p.Page > 0 {
+		p.PrevPage = pageQuery(p, p.Page-1)
+	}
+	if more {
+		p.NextPage = pageQuery(p, p.Page+1)
+	}
+
+	if key != nil {
+		if item, err := h.Tree.Search(key); err != nil {
+			p.Lookup = err.Error()
+		} else {
+			p.Lookup = fmt.Sprintf("Found: %v", item)
+		}
+	}
+}
+
+// pageQuery returns the query string that leads to page n of the listing of p.
+func pageQuery(p *page, n int) string {
+	q := url.Values{}
+	q.Set("lo", p.Lo)
+	q.Set("hi", p.Hi)
+	q.Set("page", strconv.Itoa(n))
+	return "?" + q.Encode()
+}
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head><title>goavl tree</title></head>
+<body>
+<h1>goavl tree</h1>
+{{range .Errors}}<p style="color: red">{{.}}</p>
+{{end}}
+<h2>Overview</h2>
+<table>
+<tr><td>Size</td><td>{{.Size}}</td></tr>
+<tr><td>Height</td><td>{{.Height}}</td></tr>
+<tr><td>Comparisons</td><td>{{.Stats.Comparisons}}</td></tr>
+<tr><td>Insert rotations (single/double)</td><td>{{.Stats.InsertRotations}}/{{.Stats.InsertDoubleRotations}}</td></tr>
+<tr><td>Delete rotations (single/double)</td><td>{{.Stats.DeleteRotations}}/{{.Stats.DeleteDoubleRotations}}</td></tr>
+<tr><td>Join rotations (single/double)</td><td>{{.Stats.JoinRotations}}/{{.Stats.JoinDoubleRotations}}</td></tr>
+<tr><td>Depth histogram</td><td>{{.Stats.DepthHistogram}}</td></tr>
+<tr><td>Search path length (avg/max)</td><td>{{printf "%.2f" .Stats.AvgPathLength}}/{{.Stats.MaxPathLength}}</td></tr>
+<tr><td>Height to AVL bound ratio</td><td>{{printf "%.2f" .Stats.HeightRatio}}</td></tr>
+</table>
+<h2>Lookup</h2>
+<form method="get">
+<input type="hidden" name="lo" value="{{.Lo}}"><input type="hidden" name="hi" value="{{.Hi}}">
+<input type="text" name="key" value="{{.Key}}"> <input type="submit" value="Search">
+</form>
+{{with .Lookup}}<p>{{.}}</p>{{end}}
+<h2>Keys</h2>
+<form method="get">
+[<input type="text" name="lo" value="{{.Lo}}">, <input type="text" name="hi" value="{{.Hi}}">)
+<input type="submit" value="List">
+</form>
+<p>Page {{.Page}}</p>
+<ol start="{{.First}}">
+{{range .Keys}}<li>{{.}}</li>
+{{end}}</ol>
+<p>{{with .PrevPage}}<a href="{{.}}">previous</a>{{end}} {{with .NextPage}}<a href="{{.}}">next</a>{{end}}</p>
+<h2>Shape</h2>
+<pre>{{.Shape}}</pre>
+</body>
+</html>
+`))
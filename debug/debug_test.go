@@ -0,0 +1,146 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package debug
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ckatsak/goavl"
+)
+
+type Integer int
+
+func (i Integer) Equal(j goavl.Item) bool {
+	return i == j.(Integer)
+}
+func (i Integer) Less(j goavl.Item) bool {
+	return i < j.(Integer)
+}
+
+func parseInteger(s string) (goavl.Item, error) {
+	i, err := strconv.Atoi(s)
+	return Integer(i), err
+}
+
+func newTree(t *testing.T, keys ...int) *goavl.Tree {
+	t.Helper()
+	tree := goavl.NewTree()
+	for _, key := range keys {
+		if err := tree.Insert(Integer(key)); err != nil {
+			t.Fatalf("\t%v\n", err)
+		}
+	}
+	return tree
+}
+
+func TestWriteShape(t *testing.T) {
+	var b strings.Builder
+	if err := WriteShape(&b, newTree(t, 9, 5, 10, 0, 6, 11), 0); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	expected := `9 (h=3)
+├── 5 (h=2)
+│   ├── 0 (h=1)
+│   └── 6 (h=1)
+└── 10 (h=2)
+    ├── ∅
+    └── 11 (h=1)
+`
+	if b.String() != expected {
+		t.Errorf("WriteShape wrote:\n%s\nexpected:\n%s\n", b.String(), expected)
+	}
+
+	b.Reset()
+	if err := WriteShape(&b, newTree(t, 9, 5, 10, 0, 6, 11), 1); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	if expected := "9 (h=3)\n└── …\n"; b.String() != expected {
+		t.Errorf("WriteShape wrote:\n%s\nexpected:\n%s\n", b.String(), expected)
+	}
+
+	b.Reset()
+	if err := WriteShape(&b, goavl.NewTree(), 0); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	if expected := "(empty)\n"; b.String() != expected {
+		t.Errorf("WriteShape wrote %q; expected %q\n", b.String(), expected)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	tree := newTree(t, 1, 2, 3)
+	Publish("TestPublish", tree, &sync.Mutex{})
+
+	var v struct {
+		Size, Height int
+		Stats        goavl.Stats
+	}
+	if err := json.Unmarshal([]byte(expvar.Get("TestPublish").String()), &v); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	if v.Size != 3 || v.Height != 2 {
+		t.Errorf("size, height = %d, %d; expected 3, 2\n", v.Size, v.Height)
+	}
+	if v.Stats.InsertRotations != 1 {
+		t.Errorf("stats.InsertRotations = %d; expected 1\n", v.Stats.InsertRotations)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	keys := make([]int, 100)
+	for i := range keys {
+		keys[i] = i
+	}
+	h := &Handler{
+		Tree:     newTree(t, keys...),
+		ParseKey: parseInteger,
+		PageSize: 10,
+	}
+
+	get := func(query string) string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/"+query, nil))
+		if w.Code != 200 {
+			t.Errorf("GET %s: status %d\n", query, w.Code)
+		}
+		return w.Body.String()
+	}
+
+	body := get("?lo=20&hi=50&page=1")
+	for _, s := range []string{"<li>30</li>", "<li>39</li>", "page=0", "page=2"} {
+		if !strings.Contains(body, s) {
+			t.Errorf("page does not contain %q\n", s)
+		}
+	}
+	if strings.Contains(body, "<li>40</li>") || strings.Contains(body, "<li>29</li>") {
+		t.Errorf("page contains keys outside of page 1\n")
+	}
+
+	body = get("?lo=20&hi=50&page=2")
+	if strings.Contains(body, "page=3") {
+		t.Errorf("last page links to a next page\n")
+	}
+
+	if body = get("?key=42"); !strings.Contains(body, "Found: 42") {
+		t.Errorf("lookup of existing key failed\n")
+	}
+	if body = get("?key=420"); !strings.Contains(body, "Key not found") {
+		t.Errorf("lookup of missing key succeeded\n")
+	}
+	if body = get("?key=foo"); !strings.Contains(body, "key: ") {
+		t.Errorf("invalid key not reported\n")
+	}
+}
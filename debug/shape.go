@@ -0,0 +1,95 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ckatsak/goavl"
+)
+
+// shapeNode is a node of the shape of an AVL tree, as reconstructed from its
+// pre-order traversal.
+type shapeNode struct {
+	key         goavl.Item
+	left, right *shapeNode
+	h           int
+}
+
+// height returns the height of the subtree rooted with n.
+func (n *shapeNode) height() int {
+	if n == nil {
+		return 0
+	}
+	return n.h
+}
+
+// rebuildShape reconstructs the shape of a binary search tree out of its
+// pre-order traversal, which uniquely determines it.
+func rebuildShape(preOrder []goavl.Item) *shapeNode {
+	i := 0
+	return rebuildSubtree(preOrder, &i, nil)
+}
+
+// rebuildSubtree reconstructs the subtree whose pre-order traversal starts at
+// preOrder[*i] and whose keys are all less than bound (if non-nil).
+func rebuildSubtree(preOrder []goavl.Item, i *int, bound goavl.Item) *shapeNode {
+	if *i == len(preOrder) || (bound != nil && !preOrder[*i].Less(bound)) {
+		return nil
+	}
+	n := &shapeNode{key: preOrder[*i]}
+	*i++
+	n.left = rebuildSubtree(preOrder, i, n.key)
+	n.right = rebuildSubtree(preOrder, i, bound)
+	n.h = 1 + max(n.left.height(), n.right.height())
+	return n
+}
+
+// WriteShape writes a textual representation of the shape of t to w, along
+// with the height of each node. Levels deeper than maxDepth are elided; a
+// non-positive maxDepth renders the whole tree.
+func WriteShape(w io.Writer, t *goavl.Tree, maxDepth int) error {
+	root := rebuildShape(t.PreOrder())
+	if root == nil {
+		_, err := fmt.Fprintln(w, "(empty)")
+		return err
+	}
+	return writeSubtree(w, root, "", "", 1, maxDepth)
+}
+
+// writeSubtree writes the subtree rooted with n to w, prefixing the line of n
+// with head and the lines of its descendants with indent.
+func writeSubtree(w io.Writer, n *shapeNode, head, indent string, depth, maxDepth int) error {
+	if n == nil {
+		_, err := fmt.Fprintf(w, "%s∅\n", head)
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%s%v (h=%d)\n", head, n.key, n.h); err != nil {
+		return err
+	}
+	if n.left == nil && n.right == nil {
+		return nil
+	}
+	if maxDepth > 0 && depth >= maxDepth {
+		_, err := fmt.Fprintf(w, "%s└── …\n", indent)
+		return err
+	}
+	if err := writeSubtree(w, n.left, indent+"├── ", indent+"│   ", depth+1, maxDepth); err != nil {
+		return err
+	}
+	return writeSubtree(w, n.right, indent+"└── ", indent+"    ", depth+1, maxDepth)
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
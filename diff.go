@@ -0,0 +1,172 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import "iter"
+
+// ChangeKind is the kind of a Change.
+type ChangeKind int
+
+// The kinds of Changes reported by Diff.
+const (
+	Added ChangeKind = iota
+	Removed
+	Changed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	}
+	return "unknown"
+}
+
+// Change is a difference between two AVL trees: a key that was Added to the
+// second tree (New), Removed from the first one (Old), or whose value Changed
+// from Old to New, where Old and New are equal Items.
+type Change struct {
+	Kind     ChangeKind
+	Old, New Item
+}
+
+// Diff returns an iterator over the differences between the AVL trees a and
+// b, in ascending order of their keys; see DiffFunc.
+func Diff(a, b *Tree) iter.Seq[Change] {
+	return diff(a.root, b.root, nil)
+}
+
+// DiffFunc returns an iterator over the differences between the AVL trees a
+// and b, in ascending order of their keys: the keys that exist only in b are
+// reported as Added, and those that exist only in a as Removed. Pairs of
+// equal keys that exist in both trees are passed to same, which may compare
+// the values that they carry, beyond their order; unless it returns true,
+// they are reported as Changed. A nil same is always true.
+//
+// The trees are walked lazily, in step, and must not be modified during the
+// iteration. Subtrees that are shared by both trees, as it happens to
+// Snapshots of a Versioned, are skipped as a whole, so that the cost of the
+// walk depends on the number of treeNodes that differ.
+func DiffFunc(a, b *Tree, same func(old, new Item) bool) iter.Seq[Change] {
+	return diff(a.root, b.root, same)
+}
+
+// Diff returns an iterator over the differences between the Snapshots s and
+// to, as Diff does for AVL trees. Subtrees that the two versions share are
+// skipped.
+func (s *Snapshot) Diff(to *Snapshot) iter.Seq[Change] {
+	return diff(s.root, to.root, nil)
+}
+
+// walk is the state of the in-order traversal of an AVL tree. The top of the
+// stack is the next part of the tree to visit: either a treeNode alone, or a
+// whole subtree.
+type walk []walkFrame
+
+type walkFrame struct {
+	n       *treeNode
+	subtree bool
+}
+
+func (w *walk) top() walkFrame {
+	return (*w)[len(*w)-1]
+}
+
+func (w *walk) pop() {
+	*w = (*w)[:len(*w)-1]
+}
+
+// expand replaces the subtree at the top of the stack with its parts: its
+// left subtree, its root and its right subtree.
+func (w *walk) expand() {
+	n := w.top().n
+	w.pop()
+	if n != nil {
+		*w = append(*w, walkFrame{n.right, true}, walkFrame{n, false}, walkFrame{n.left, true})
+	}
+}
+
+// diff returns an iterator over the differences between the AVL subtrees
+// rooted with a and b; see DiffFunc.
+func diff(a, b *treeNode, same func(old, new Item) bool) iter.Seq[Change] {
+	return func(yield func(Change) bool) {
+		wa, wb := walk{{a, true}}, walk{{b, true}}
+		for len(wa) > 0 || len(wb) > 0 {
+			switch {
+			case len(wb) == 0:
+				fa := wa.top()
+				if fa.subtree {
+					wa.expand()
+					continue
+				}
+				wa.pop()
+				if !yield(Change{Kind: Removed, Old: fa.n.key}) {
+					return
+				}
+			case len(wa) == 0:
+				fb := wb.top()
+				if fb.subtree {
+					wb.expand()
+					continue
+				}
+				wb.pop()
+				if !yield(Change{Kind: Added, New: fb.n.key}) {
+					return
+				}
+			default:
+				fa, fb := wa.top(), wb.top()
+				switch {
+				case fa.subtree && fb.subtree:
+					// Skip shared subtrees; otherwise, expand the
+					// taller one first, so that those which are
+					// shared get to the tops in step.
+					ha, hb := fa.n.height(), fb.n.height()
+					switch {
+					case fa.n == fb.n:
+						wa.pop()
+						wb.pop()
+					case ha > hb:
+						wa.expand()
+					case hb > ha:
+						wb.expand()
+					default:
+						wa.expand()
+						wb.expand()
+					}
+				case fa.subtree:
+					wa.expand()
+				case fb.subtree:
+					wb.expand()
+				case fa.n.key.Less(fb.n.key):
+					wa.pop()
+					if !yield(Change{Kind: Removed, Old: fa.n.key}) {
+						return
+					}
+				case fb.n.key.Less(fa.n.key):
+					wb.pop()
+					if !yield(Change{Kind: Added, New: fb.n.key}) {
+						return
+					}
+				default:
+					wa.pop()
+					wb.pop()
+					if fa.n != fb.n && same != nil && !same(fa.n.key, fb.n.key) {
+						if !yield(Change{Kind: Changed, Old: fa.n.key, New: fb.n.key}) {
+							return
+						}
+					}
+				}
+			}
+		}
+	}
+}
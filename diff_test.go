@@ -0,0 +1,141 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// collect returns the Changes yielded by seq, formatted.
+func collect(seq func(func(Change) bool)) []string {
+	ret := []string{}
+	for c := range seq {
+		switch c.Kind {
+		case Added:
+			ret = append(ret, fmt.Sprintf("+%v", c.New))
+		case Removed:
+			ret = append(ret, fmt.Sprintf("-%v", c.Old))
+		default:
+			ret = append(ret, fmt.Sprintf("%v:%v->%v", c.Kind, c.Old, c.New))
+		}
+	}
+	return ret
+}
+
+func TestDiff(t *testing.T) {
+	for iter := 0; iter < 100; iter++ {
+		a, b := NewTree(), NewTree()
+		inA, inB := map[int]bool{}, map[int]bool{}
+		for i := 0; i < rand.Intn(300); i++ {
+			key := rand.Intn(400)
+			if rand.Intn(2) == 0 && a.Insert(Integer(key)) == nil {
+				inA[key] = true
+			}
+			if rand.Intn(2) == 0 && b.Insert(Integer(key)) == nil {
+				inB[key] = true
+			}
+		}
+		expected := []string{}
+		for key := 0; key < 400; key++ {
+			switch {
+			case inA[key] && !inB[key]:
+				expected = append(expected, fmt.Sprintf("-%d", key))
+			case inB[key] && !inA[key]:
+				expected = append(expected, fmt.Sprintf("+%d", key))
+			}
+		}
+		if got := collect(Diff(a, b)); fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Fatalf("Diff() = %v; expected %v\n", got, expected)
+		}
+	}
+
+	// The iteration stops early.
+	a, b := newIntegerTree(t, 1, 2, 3), newIntegerTree(t)
+	for range Diff(a, b) {
+		break
+	}
+}
+
+// pair is an Item ordered by its key alone, carrying a value.
+type pair struct {
+	key, value int
+}
+
+func (p pair) Equal(to Item) bool {
+	return p.key == to.(pair).key
+}
+
+func (p pair) Less(than Item) bool {
+	return p.key < than.(pair).key
+}
+
+func (p pair) String() string {
+	return fmt.Sprintf("%d=%d", p.key, p.value)
+}
+
+func TestDiffFunc(t *testing.T) {
+	a, b := NewTree(), NewTree()
+	for _, p := range []pair{{1, 1}, {2, 2}, {3, 3}, {5, 5}} {
+		a.Insert(p)
+	}
+	for _, p := range []pair{{2, 2}, {3, 4}, {4, 4}, {5, 6}} {
+		b.Insert(p)
+	}
+	same := func(old, new Item) bool { return old.(pair).value == new.(pair).value }
+	got := fmt.Sprint(collect(DiffFunc(a, b, same)))
+	if expected := "[-1=1 changed:3=3->3=4 +4=4 changed:5=5->5=6]"; got != expected {
+		t.Errorf("DiffFunc() = %v; expected %v\n", got, expected)
+	}
+	if got, expected := fmt.Sprint(collect(Diff(a, b))), "[-1=1 +4=4]"; got != expected {
+		t.Errorf("Diff() = %v; expected %v\n", got, expected)
+	}
+}
+
+// countingInt is an Item that counts the comparisons it takes part in.
+type countingInt struct {
+	i int
+	n *int
+}
+
+func (c countingInt) Equal(to Item) bool {
+	*c.n++
+	return c.i == to.(countingInt).i
+}
+
+func (c countingInt) Less(than Item) bool {
+	*c.n++
+	return c.i < than.(countingInt).i
+}
+
+func TestDiffSharedSubtrees(t *testing.T) {
+	comparisons := 0
+	v := NewVersioned(0)
+	for i := 0; i < 1<<14; i++ {
+		v.Insert(countingInt{2 * i, &comparisons})
+	}
+	before := v.Current()
+	v.Insert(countingInt{1001, &comparisons})
+	v.Delete(countingInt{5000, &comparisons})
+	after := v.Current()
+
+	comparisons = 0
+	changes := []Change{}
+	for c := range before.Diff(after) {
+		changes = append(changes, c)
+	}
+	if len(changes) != 2 || changes[0].Kind != Added || changes[1].Kind != Removed {
+		t.Fatalf("Diff() = %v; expected 1001 added and 5000 removed\n", changes)
+	}
+	if comparisons > 200 {
+		t.Errorf("Diff() of two versions took %d comparisons; shared subtrees were not skipped\n", comparisons)
+	}
+	t.Logf("Diff() of two versions of %d keys took %d comparisons\n", after.Size(), comparisons)
+}
@@ -0,0 +1,277 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// expEntry is a key of an ExpiringTree, along with its expiry.
+type expEntry struct {
+	key    Item
+	expiry time.Time
+}
+
+// byKey is the Item stored in the primary tree of an ExpiringTree, ordered by
+// the keys of the entries.
+type byKey struct {
+	*expEntry
+}
+
+func (e byKey) Equal(to Item) bool {
+	return e.key.Equal(to.(byKey).key)
+}
+
+func (e byKey) Less(than Item) bool {
+	return e.key.Less(than.(byKey).key)
+}
+
+// byExpiry is the Item stored in the secondary tree of an ExpiringTree,
+// ordered by the expiries of the entries, and then by their keys.
+type byExpiry struct {
+	*expEntry
+}
+
+func (e byExpiry) Equal(to Item) bool {
+	f := to.(byExpiry)
+	return e.expiry.Equal(f.expiry) && e.key.Equal(f.key)
+}
+
+func (e byExpiry) Less(than Item) bool {
+	f := than.(byExpiry)
+	if !e.expiry.Equal(f.expiry) {
+		return e.expiry.Before(f.expiry)
+	}
+	return e.key.Less(f.key)
+}
+
+// ExpiringTree is an AVL tree whose keys expire at given points in time. Keys
+// are hidden from lookups as soon as they expire, but they are only removed
+// by Sweep, which may be called periodically by a janitor goroutine, and by
+// Min and Max, which sweep them lazily. It is safe for concurrent use.
+type ExpiringTree struct {
+	mu       sync.RWMutex
+	now      func() time.Time
+	keys     Tree // of byKey
+	expiries Tree // of byExpiry; keys that never expire are not included
+}
+
+// NewExpiringTree creates a new empty ExpiringTree, which uses now to tell the
+// current time. If now is nil, time.Now is used.
+func NewExpiringTree(now func() time.Time) *ExpiringTree {
+	if now == nil {
+		now = time.Now
+	}
+	return &ExpiringTree{now: now}
+}
+
+// expired reports whether e has expired at the given time. A zero expiry
+// never expires.
+func (e *expEntry) expired(now time.Time) bool {
+	return !e.expiry.IsZero() && !now.Before(e.expiry)
+}
+
+// search returns the entry of key, expired or not, or nil if there is none.
+func (t *ExpiringTree) search(key Item) *expEntry {
+	if n := t.keys.root.subtreeSearch(byKey{&expEntry{key: key}}); n != nil {
+		return n.key.(byKey).expEntry
+	}
+	return nil
+}
+
+// remove removes e from both trees.
+func (t *ExpiringTree) remove(e *expEntry) {
+	t.keys.Delete(byKey{e})
+	if !e.expiry.IsZero() {
+		t.expiries.Delete(byExpiry{e})
+	}
+}
+
+// Size returns the current number of keys in the ExpiringTree, including any
+// expired ones that have not been swept yet.
+func (t *ExpiringTree) Size() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.keys.Size()
+}
+
+// Insert inserts key into the ExpiringTree, to expire at the given time; a
+// zero expiry means that key never expires. It returns an error value, which
+// is non-nil if key already exists in the tree and has not expired yet.
+// Expired keys are replaced.
+func (t *ExpiringTree) Insert(key Item, expiry time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e := t.search(key); e != nil {
+		if !e.expired(t.now()) {
+			return fmt.Errorf("Key already in the tree: %v", key)
+		}
+		t.remove(e)
+	}
+	e := &expEntry{key: key, expiry: expiry}
+	t.keys.Insert(byKey{e})
+	if !expiry.IsZero() {
+		t.expiries.Insert(byExpiry{e})
+	}
+	return nil
+}
+
+// Touch changes the expiry of key, e.g. in order to extend a lease. It returns
+// an error value, which is non-nil if key doesn't exist in the tree or has
+// already expired.
+func (t *ExpiringTree) Touch(key Item, expiry time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e := t.search(key)
+	if e == nil || e.expired(t.now()) {
+		return fmt.Errorf("Key not found in the tree: %v", key)
+	}
+	if !e.expiry.IsZero() {
+		t.expiries.Delete(byExpiry{e})
+	}
+	e.expiry = expiry
+	if !expiry.IsZero() {
+		t.expiries.Insert(byExpiry{e})
+	}
+	return nil
+}
+
+// Delete removes key from the ExpiringTree and returns an error value, which
+// is non-nil if key doesn't exist in the tree or has already expired (in which
+// case it is removed anyway).
+func (t *ExpiringTree) Delete(key Item) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e := t.search(key)
+	if e == nil {
+		return fmt.Errorf("Key not found in the tree: %v", key)
+	}
+	t.remove(e)
+	if e.expired(t.now()) {
+		return fmt.Errorf("Key not found in the tree: %v", key)
+	}
+	return nil
+}
+
+// Search looks up key in the ExpiringTree and returns the Item stored in it
+// that is equal to key along with its expiry, and an error value, which is
+// non-nil if the key doesn't exist in the tree or has expired.
+func (t *ExpiringTree) Search(key Item) (Item, time.Time, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	e := t.search(key)
+	if e == nil || e.expired(t.now()) {
+		return nil, time.Time{}, fmt.Errorf("Key not found in the tree: %v", key)
+	}
+	return e.key, e.expiry, nil
+}
+
+// Contains reports whether key exists in the ExpiringTree and has not
+// expired.
+func (t *ExpiringTree) Contains(key Item) bool {
+	_, _, err := t.Search(key)
+	return err == nil
+}
+
+// Min returns the minimum key in the ExpiringTree that has not expired, and an
+// error value. If there is no such key, the error value is non-nil and the
+// result should not be trusted. Expired keys are swept first, so that Min
+// takes amortized O(log n) time.
+func (t *ExpiringTree) Min() (Item, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sweep(t.now())
+	min, err := t.keys.Min()
+	if err != nil {
+		return nil, err
+	}
+	return min.(byKey).key, nil
+}
+
+// Max returns the maximum key in the ExpiringTree that has not expired, and an
+// error value. If there is no such key, the error value is non-nil and the
+// result should not be trusted. Expired keys are swept first, so that Max
+// takes amortized O(log n) time.
+func (t *ExpiringTree) Max() (Item, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sweep(t.now())
+	max, err := t.keys.Max()
+	if err != nil {
+		return nil, err
+	}
+	return max.(byKey).key, nil
+}
+
+// Ascend calls visit for each key in the ExpiringTree that has not expired,
+// along with its expiry, in ascending order, until visit returns false. visit
+// must not modify the tree.
+func (t *ExpiringTree) Ascend(visit func(key Item, expiry time.Time) bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	now := t.now()
+	t.keys.AscendRange(nil, nil, func(item Item) bool {
+		if e := item.(byKey); !e.expired(now) {
+			return visit(e.key, e.expiry)
+		}
+		return true
+	})
+}
+
+// Sweep removes all keys that have expired at the given time from the
+// ExpiringTree, and returns their number. It takes O(log n) time per removed
+// key.
+func (t *ExpiringTree) Sweep(now time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sweep(now)
+}
+
+// sweep is Sweep, with t.mu held.
+func (t *ExpiringTree) sweep(now time.Time) int {
+	removed := 0
+	for t.expiries.root != nil {
+		e := t.expiries.root.subtreeMin().key.(byExpiry)
+		if !e.expired(now) {
+			break
+		}
+		t.expiries.PopMin()
+		t.keys.Delete(byKey{e.expEntry})
+		removed++
+	}
+	return removed
+}
+
+// StartJanitor starts a goroutine that calls Sweep every interval, until ctx is
+// done. It returns a channel which is closed when the goroutine exits, along
+// with an error value, which is non-nil if interval is not positive, in which
+// case no goroutine is started and the channel is nil.
+func (t *ExpiringTree) StartJanitor(ctx context.Context, interval time.Duration) (<-chan struct{}, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("Non-positive janitor interval: %v", interval)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				t.Sweep(t.now())
+			}
+		}
+	}()
+	return done, nil
+}
@@ -0,0 +1,143 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock for ExpiringTrees that only moves when advanced.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestExpiringTree(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	tree := NewExpiringTree(clock.Now)
+	at := func(s int) time.Time { return clock.Now().Add(time.Duration(s) * time.Second) }
+
+	for key, s := range map[int]int{1: 10, 2: 30, 3: 20, 4: 0, 5: 10} {
+		var expiry time.Time
+		if s > 0 {
+			expiry = at(s)
+		}
+		if err := tree.Insert(Integer(key), expiry); err != nil {
+			t.Errorf("\t%v\n", err)
+		}
+	}
+	if err := tree.Insert(Integer(1), at(100)); err == nil {
+		t.Errorf("\tExpected an error for inserting a live key!\n")
+	}
+
+	// Keys 1 and 5 expire, but are only hidden until swept, which Min and
+	// Max do lazily.
+	clock.Advance(10 * time.Second)
+	if tree.Contains(Integer(1)) || !tree.Contains(Integer(3)) {
+		t.Errorf("Contains does not respect expiries\n")
+	}
+	if size := tree.Size(); size != 5 {
+		t.Errorf("Size() = %d; expected 5\n", size)
+	}
+	if min, err := tree.Min(); err != nil || min != Integer(2) {
+		t.Errorf("Min() = %v, %v; expected 2\n", min, err)
+	}
+	if max, err := tree.Max(); err != nil || max != Integer(4) {
+		t.Errorf("Max() = %v, %v; expected 4\n", max, err)
+	}
+	if size := tree.Size(); size != 3 {
+		t.Errorf("Size() = %d; expected 3\n", size)
+	}
+
+	// An expired key can be inserted again; a live one can be extended.
+	if err := tree.Insert(Integer(5), at(5)); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if err := tree.Touch(Integer(3), at(100)); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if err := tree.Touch(Integer(1), at(100)); err == nil {
+		t.Errorf("\tExpected an error for touching an expired key!\n")
+	}
+	if _, expiry, err := tree.Search(Integer(3)); err != nil || !expiry.Equal(at(100)) {
+		t.Errorf("Search(3) = %v, %v; expected expiry %v\n", expiry, err, at(100))
+	}
+
+	clock.Advance(20 * time.Second)
+	if n := tree.Sweep(clock.Now()); n != 2 {
+		t.Errorf("Sweep() = %d; expected 2 (keys 2 and 5)\n", n)
+	}
+	keys := []Item{}
+	tree.Ascend(func(key Item, _ time.Time) bool {
+		keys = append(keys, key)
+		return true
+	})
+	verifyItems(t, keys, 3, 4)
+	if size := tree.Size(); size != 2 {
+		t.Errorf("Size() = %d; expected 2\n", size)
+	}
+
+	if err := tree.Delete(Integer(4)); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	clock.Advance(100 * time.Second)
+	if err := tree.Delete(Integer(3)); err == nil {
+		t.Errorf("\tExpected an error for deleting an expired key!\n")
+	}
+	if _, err := tree.Min(); err == nil {
+		t.Errorf("\tExpected an error for an empty tree!\n")
+	}
+	if size := tree.Size(); size != 0 {
+		t.Errorf("Size() = %d; expected 0\n", size)
+	}
+}
+
+func TestExpiringTreeJanitor(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	tree := NewExpiringTree(clock.Now)
+	tree.Insert(Integer(1), clock.Now().Add(time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if done, err := tree.StartJanitor(ctx, interval); err == nil || done != nil {
+			t.Errorf("StartJanitor(%v) = %v, %v; expected an error\n", interval, done, err)
+		} else {
+			t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+		}
+	}
+	done, err := tree.StartJanitor(ctx, time.Millisecond)
+	if err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	clock.Advance(time.Second)
+	for deadline := time.Now().Add(5 * time.Second); tree.Size() != 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("the janitor did not sweep the expired key\n")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+}
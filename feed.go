@@ -0,0 +1,228 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Record is a mutation of an AVL tree, as emitted by a Feed. Records are
+// numbered by consecutive sequence numbers, starting from 1.
+type Record struct {
+	Seq uint64
+	Op
+}
+
+// Feed is an AVL tree that records its mutations, so that they can be
+// replicated to followers. It is safe for concurrent use.
+//
+// The tree that a Feed is created with must only be mutated through the
+// Feed, or else the mutations will not be recorded.
+type Feed struct {
+	mu         sync.Mutex
+	changed    chan struct{} // closed and replaced upon each mutation
+	tree       *Tree
+	records    []Record // retained records, in order
+	seq        uint64   // sequence number of the last record
+	maxRecords int
+}
+
+// NewFeed creates a new Feed over tree, which retains at most maxRecords
+// records, dropping the oldest ones as needed. If maxRecords is 0, all
+// records are retained.
+func NewFeed(tree *Tree, maxRecords int) *Feed {
+	return &Feed{changed: make(chan struct{}), tree: tree, maxRecords: maxRecords}
+}
+
+// Seq returns the sequence number of the last record, or 0 if there is none.
+func (f *Feed) Seq() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.seq
+}
+
+// apply applies op to the tree and records it, if it succeeds.
+func (f *Feed) apply(op Op) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var err error
+	if op.Kind == OpInsert {
+		err = f.tree.Insert(op.Key)
+	} else {
+		err = f.tree.Delete(op.Key)
+	}
+	if err != nil {
+		return err
+	}
+
+	f.seq++
+	f.records = append(f.records, Record{Seq: f.seq, Op: op})
+	if f.maxRecords > 0 && len(f.records) > f.maxRecords {
+		n := len(f.records) - f.maxRecords
+		clear(f.records[:n])
+		f.records = f.records[n:]
+	}
+	close(f.changed)
+	f.changed = make(chan struct{})
+	return nil
+}
+
+// Insert inserts a key into the tree, and records it; see Tree.Insert.
+func (f *Feed) Insert(key Item) error {
+	return f.apply(Op{Kind: OpInsert, Key: key})
+}
+
+// Delete removes a key from the tree, and records it; see Tree.Delete.
+func (f *Feed) Delete(key Item) error {
+	return f.apply(Op{Kind: OpDelete, Key: key})
+}
+
+// Read calls fn with the tree, which fn must not modify, while holding the
+// lock of the Feed.
+func (f *Feed) Read(fn func(*Tree)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn(f.tree)
+}
+
+// Snapshot returns the keys of the tree, in ascending order, along with the
+// sequence number of the last record applied to it. A follower that is too
+// far behind for Since can start over from it.
+func (f *Feed) Snapshot() ([]Item, uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tree.InOrder(), f.seq
+}
+
+// Since returns the records whose sequence numbers are greater than seq, and
+// an error value, which is non-nil if some of them are no longer retained.
+func (f *Feed) Since(seq uint64) ([]Record, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.since(seq)
+}
+
+func (f *Feed) since(seq uint64) ([]Record, error) {
+	if seq >= f.seq {
+		return nil, nil
+	}
+	first := f.seq - uint64(len(f.records)) + 1
+	if seq+1 < first {
+		return nil, fmt.Errorf("Records no longer retained: %d to %d", seq+1, first-1)
+	}
+	return append([]Record{}, f.records[seq+1-first:]...), nil
+}
+
+// Wait waits until there are records whose sequence numbers are greater than
+// seq, and returns them, as Since. It returns early, with ctx.Err(), if ctx is
+// done.
+func (f *Feed) Wait(ctx context.Context, seq uint64) ([]Record, error) {
+	for {
+		f.mu.Lock()
+		records, err := f.since(seq)
+		changed := f.changed
+		f.mu.Unlock()
+		if err != nil || len(records) > 0 {
+			return records, err
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Serve encodes the records whose sequence numbers are greater than seq into
+// enc, as they are emitted, until ctx is done or an error occurs, which it
+// returns.
+func (f *Feed) Serve(ctx context.Context, seq uint64, enc *RecordEncoder) error {
+	for {
+		records, err := f.Wait(ctx, seq)
+		if err != nil {
+			return err
+		}
+		for _, rec := range records {
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+		}
+		seq = records[len(records)-1].Seq
+	}
+}
+
+// Follower applies the records of a Feed to its own AVL tree, in order to
+// replicate it. It is not safe for concurrent use.
+type Follower struct {
+	tree *Tree
+	seq  uint64
+}
+
+// NewFollower creates a new Follower that applies records to tree, which must
+// hold the keys of the Feed as of the record numbered seq, e.g. as returned by
+// Feed.Snapshot. An empty tree and 0 start from the beginning.
+func NewFollower(tree *Tree, seq uint64) *Follower {
+	return &Follower{tree: tree, seq: seq}
+}
+
+// Tree returns the tree of the Follower.
+func (f *Follower) Tree() *Tree {
+	return f.tree
+}
+
+// Seq returns the sequence number of the last record applied, from which the
+// Follower can resume.
+func (f *Follower) Seq() uint64 {
+	return f.seq
+}
+
+// Apply applies rec to the tree of the Follower. Records that have already
+// been applied are ignored, so that resuming from an earlier sequence number
+// is harmless. It returns an error value, which is non-nil if rec does not
+// follow the last applied record, or if it fails to apply, which means that
+// the tree has diverged from that of the Feed.
+func (f *Follower) Apply(rec Record) error {
+	if rec.Seq <= f.seq {
+		return nil
+	}
+	if rec.Seq != f.seq+1 {
+		return fmt.Errorf("Gap in the records: expected %d, got %d", f.seq+1, rec.Seq)
+	}
+	var err error
+	switch rec.Kind {
+	case OpInsert:
+		err = f.tree.Insert(rec.Key)
+	case OpDelete:
+		err = f.tree.Delete(rec.Key)
+	default:
+		err = fmt.Errorf("Unknown operation kind: %d", rec.Kind)
+	}
+	if err != nil {
+		return fmt.Errorf("Applying record %d: %v", rec.Seq, err)
+	}
+	f.seq = rec.Seq
+	return nil
+}
+
+// Follow decodes records from dec and applies them, until dec returns an
+// error (e.g. io.EOF at the end of the stream), or applying fails. It returns
+// that error.
+func (f *Follower) Follow(dec *RecordDecoder) error {
+	for {
+		rec, err := dec.Decode()
+		if err != nil {
+			return err
+		}
+		if err := f.Apply(rec); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// mutateFeed performs n random mutations through feed.
+func mutateFeed(feed *Feed, n int) {
+	for i := 0; i < n; i++ {
+		key := Int(rand.Intn(100))
+		if rand.Intn(3) == 0 {
+			feed.Delete(key)
+		} else {
+			feed.Insert(key)
+		}
+	}
+}
+
+// verifyReplica checks that the tree of the follower holds the same keys as
+// that of the feed.
+func verifyReplica(t *testing.T, feed *Feed, follower *Follower) {
+	t.Helper()
+	keys, seq := feed.Snapshot()
+	if follower.Seq() != seq {
+		t.Fatalf("follower at %d; expected %d\n", follower.Seq(), seq)
+	}
+	if got, expected := fmt.Sprint(follower.Tree().InOrder()), fmt.Sprint(keys); got != expected {
+		t.Fatalf("follower holds %v; expected %v\n", got, expected)
+	}
+}
+
+func TestFeed(t *testing.T) {
+	feed := NewFeed(NewTree(), 0)
+	if err := feed.Insert(Int(1)); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if err := feed.Insert(Int(1)); err == nil {
+		t.Errorf("\tExpected an error for inserting a duplicate key!\n")
+	}
+	if err := feed.Delete(Int(1)); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	records, err := feed.Since(0)
+	if err != nil || len(records) != 2 || feed.Seq() != 2 {
+		t.Fatalf("Since(0) = %v, %v; expected 2 records\n", records, err)
+	}
+	if records[0] != (Record{1, Op{OpInsert, Int(1)}}) || records[1] != (Record{2, Op{OpDelete, Int(1)}}) {
+		t.Errorf("Since(0) = %v\n", records)
+	}
+
+	// Followers catch up, and resuming from earlier records is harmless.
+	mutateFeed(feed, 500)
+	follower := NewFollower(NewTree(), 0)
+	for _, from := range []uint64{0, 100, 50, 300} {
+		records, err := feed.Since(from)
+		if err != nil {
+			t.Fatalf("\t%v\n", err)
+		}
+		for _, rec := range records {
+			if err := follower.Apply(rec); err != nil {
+				t.Fatalf("\t%v\n", err)
+			}
+		}
+	}
+	verifyReplica(t, feed, follower)
+
+	if err := follower.Apply(Record{Seq: follower.Seq() + 2, Op: Op{OpInsert, Int(1000)}}); err == nil {
+		t.Errorf("\tExpected an error for a gap in the records!\n")
+	} else {
+		t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+	}
+}
+
+func TestFeedRetention(t *testing.T) {
+	feed := NewFeed(NewTree(), 10)
+	mutateFeed(feed, 100)
+	seq := feed.Seq()
+	if records, err := feed.Since(seq - 10); err != nil || len(records) != 10 {
+		t.Errorf("Since(%d) = %v, %v; expected 10 records\n", seq-10, records, err)
+	}
+	if _, err := feed.Since(seq - 11); err == nil {
+		t.Errorf("\tExpected an error for records no longer retained!\n")
+	} else {
+		t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+	}
+
+	// A follower that is too far behind starts over from a snapshot.
+	keys, seq := feed.Snapshot()
+	tree := NewTree()
+	for _, key := range keys {
+		tree.Insert(key)
+	}
+	follower := NewFollower(tree, seq)
+	mutateFeed(feed, 5)
+	records, _ := feed.Since(follower.Seq())
+	for _, rec := range records {
+		if err := follower.Apply(rec); err != nil {
+			t.Fatalf("\t%v\n", err)
+		}
+	}
+	verifyReplica(t, feed, follower)
+}
+
+// countingWriter counts the calls to its Write method.
+type countingWriter struct {
+	io.Writer
+	writes atomic.Uint64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	defer w.writes.Add(1)
+	return w.Writer.Write(b)
+}
+
+func TestFeedServe(t *testing.T) {
+	feed := NewFeed(NewTree(), 0)
+	mutateFeed(feed, 100)
+
+	pr, pw := io.Pipe()
+	w := &countingWriter{Writer: pw}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- feed.Serve(ctx, 0, NewRecordEncoder(w, IntCodec))
+		pw.Close()
+	}()
+
+	follower := NewFollower(NewTree(), 0)
+	followed := make(chan error, 1)
+	go func() {
+		followed <- follower.Follow(NewRecordDecoder(pr, IntCodec))
+	}()
+
+	// Records emitted while serving reach the follower as well. Each one
+	// is written with a single call to Write.
+	mutateFeed(feed, 100)
+	for deadline := time.Now().Add(5 * time.Second); w.writes.Load() != feed.Seq(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("the feed did not serve all records\n")
+		}
+	}
+	cancel()
+	if err := <-served; err != context.Canceled {
+		t.Errorf("Serve() = %v; expected %v\n", err, context.Canceled)
+	}
+	if err := <-followed; err != io.EOF {
+		t.Errorf("Follow() = %v; expected %v\n", err, io.EOF)
+	}
+	verifyReplica(t, feed, follower)
+}
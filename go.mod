@@ -0,0 +1,3 @@
+module github.com/ckatsak/goavl
+
+go 1.23
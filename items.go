@@ -0,0 +1,210 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"bytes"
+	"math"
+	"time"
+)
+
+// Int is an Item of int.
+type Int int
+
+// Equal implements Item.
+func (i Int) Equal(to Item) bool {
+	return i == to.(Int)
+}
+
+// Less implements Item.
+func (i Int) Less(than Item) bool {
+	return i < than.(Int)
+}
+
+// Int64 is an Item of int64.
+type Int64 int64
+
+// Equal implements Item.
+func (i Int64) Equal(to Item) bool {
+	return i == to.(Int64)
+}
+
+// Less implements Item.
+func (i Int64) Less(than Item) bool {
+	return i < than.(Int64)
+}
+
+// Uint64 is an Item of uint64.
+type Uint64 uint64
+
+// Equal implements Item.
+func (u Uint64) Equal(to Item) bool {
+	return u == to.(Uint64)
+}
+
+// Less implements Item.
+func (u Uint64) Less(than Item) bool {
+	return u < than.(Uint64)
+}
+
+// Float64 is an Item of float64.
+//
+// Unlike the builtin comparison operators, Float64 defines a total order over
+// all float64 values: all NaNs are equal to each other and less than any other
+// value (including -Inf), and -0 is less than +0.
+type Float64 float64
+
+// Equal implements Item.
+func (f Float64) Equal(to Item) bool {
+	g := to.(Float64)
+	if math.IsNaN(float64(f)) || math.IsNaN(float64(g)) {
+		return math.IsNaN(float64(f)) && math.IsNaN(float64(g))
+	}
+	return f == g && math.Signbit(float64(f)) == math.Signbit(float64(g))
+}
+
+// Less implements Item.
+func (f Float64) Less(than Item) bool {
+	g := than.(Float64)
+	switch {
+	case math.IsNaN(float64(g)):
+		return false
+	case math.IsNaN(float64(f)):
+		return true
+	case f == g: // -0 and +0
+		return math.Signbit(float64(f)) && !math.Signbit(float64(g))
+	}
+	return f < g
+}
+
+// String is an Item of string.
+type String string
+
+// Equal implements Item.
+func (s String) Equal(to Item) bool {
+	return s == to.(String)
+}
+
+// Less implements Item.
+func (s String) Less(than Item) bool {
+	return s < than.(String)
+}
+
+// ByteSize implements Sizer.
+func (s String) ByteSize() int {
+	return len(s)
+}
+
+// Bytes is an Item of []byte, ordered lexicographically as by bytes.Compare.
+// A Bytes must not be modified while it is in a tree.
+type Bytes []byte
+
+// Equal implements Item.
+func (b Bytes) Equal(to Item) bool {
+	return bytes.Equal(b, to.(Bytes))
+}
+
+// Less implements Item.
+func (b Bytes) Less(than Item) bool {
+	return bytes.Compare(b, than.(Bytes)) < 0
+}
+
+// ByteSize implements Sizer.
+func (b Bytes) ByteSize() int {
+	return cap(b)
+}
+
+// Time is an Item of time.Time. Times are compared as instants, as by the
+// Equal and Before methods of time.Time, regardless of their locations.
+type Time struct {
+	time.Time
+}
+
+// Equal implements Item.
+func (t Time) Equal(to Item) bool {
+	return t.Time.Equal(to.(Time).Time)
+}
+
+// Less implements Item.
+func (t Time) Less(than Item) bool {
+	return t.Time.Before(than.(Time).Time)
+}
+
+// Duration is an Item of time.Duration.
+type Duration time.Duration
+
+// Equal implements Item.
+func (d Duration) Equal(to Item) bool {
+	return d == to.(Duration)
+}
+
+// Less implements Item.
+func (d Duration) Less(than Item) bool {
+	return d < than.(Duration)
+}
+
+// String returns the string form of d, as formatted by time.Duration.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// InsertAll inserts the given keys into t, after converting each of them into
+// an Item with conv. It keeps inserting after any failures and returns the
+// error of the first one.
+func InsertAll[T any](t *Tree, conv func(T) Item, keys ...T) error {
+	var first error
+	for _, key := range keys {
+		if err := t.Insert(conv(key)); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// InsertInts inserts the given ints into t as Ints; see InsertAll.
+func InsertInts(t *Tree, keys ...int) error {
+	return InsertAll(t, func(k int) Item { return Int(k) }, keys...)
+}
+
+// InsertInt64s inserts the given int64s into t as Int64s; see InsertAll.
+func InsertInt64s(t *Tree, keys ...int64) error {
+	return InsertAll(t, func(k int64) Item { return Int64(k) }, keys...)
+}
+
+// InsertUint64s inserts the given uint64s into t as Uint64s; see InsertAll.
+func InsertUint64s(t *Tree, keys ...uint64) error {
+	return InsertAll(t, func(k uint64) Item { return Uint64(k) }, keys...)
+}
+
+// InsertFloat64s inserts the given float64s into t as Float64s; see
+// InsertAll.
+func InsertFloat64s(t *Tree, keys ...float64) error {
+	return InsertAll(t, func(k float64) Item { return Float64(k) }, keys...)
+}
+
+// InsertStrings inserts the given strings into t as Strings; see InsertAll.
+func InsertStrings(t *Tree, keys ...string) error {
+	return InsertAll(t, func(k string) Item { return String(k) }, keys...)
+}
+
+// InsertBytes inserts the given byte slices into t as Bytes; see InsertAll.
+func InsertBytes(t *Tree, keys ...[]byte) error {
+	return InsertAll(t, func(k []byte) Item { return Bytes(k) }, keys...)
+}
+
+// InsertTimes inserts the given times into t as Times; see InsertAll.
+func InsertTimes(t *Tree, keys ...time.Time) error {
+	return InsertAll(t, func(k time.Time) Item { return Time{k} }, keys...)
+}
+
+// InsertDurations inserts the given durations into t as Durations; see
+// InsertAll.
+func InsertDurations(t *Tree, keys ...time.Duration) error {
+	return InsertAll(t, func(k time.Duration) Item { return Duration(k) }, keys...)
+}
@@ -0,0 +1,114 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ckatsak/goavl"
+	"github.com/ckatsak/goavl/avltest"
+)
+
+func TestItemsOrdering(t *testing.T) {
+	now := time.Now()
+	for name, items := range map[string][]goavl.Item{
+		"Int":    {goavl.Int(math.MinInt64), goavl.Int(-1), goavl.Int(0), goavl.Int(0), goavl.Int(42)},
+		"Int64":  {goavl.Int64(math.MinInt64), goavl.Int64(0), goavl.Int64(math.MaxInt64)},
+		"Uint64": {goavl.Uint64(0), goavl.Uint64(1), goavl.Uint64(math.MaxUint64)},
+		"Float64": {
+			goavl.Float64(math.NaN()), goavl.Float64(-math.NaN()), goavl.Float64(math.Inf(-1)),
+			goavl.Float64(-1.5), goavl.Float64(math.Copysign(0, -1)), goavl.Float64(0),
+			goavl.Float64(math.SmallestNonzeroFloat64), goavl.Float64(math.Inf(1)),
+		},
+		"String": {goavl.String(""), goavl.String("a"), goavl.String("ab"), goavl.String("b")},
+		"Bytes":  {goavl.Bytes(nil), goavl.Bytes{}, goavl.Bytes{0}, goavl.Bytes{0, 1}, goavl.Bytes{1}},
+		"Time": {
+			goavl.Time{now}, goavl.Time{now.UTC()}, goavl.Time{now.Add(-time.Second)},
+			goavl.Time{time.Time{}},
+		},
+		"Duration": {goavl.Duration(-time.Hour), goavl.Duration(0), goavl.Duration(time.Nanosecond)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			avltest.CheckOrdering(t, items)
+		})
+	}
+}
+
+func TestFloat64Order(t *testing.T) {
+	tree := goavl.NewTree()
+	if err := goavl.InsertFloat64s(tree, 1, math.Copysign(0, -1), math.NaN(), 0, math.Inf(-1)); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	if err := goavl.InsertFloat64s(tree, math.NaN()); err == nil {
+		t.Errorf("\tExpected an error for a second NaN!\n")
+	}
+
+	keys := tree.InOrder()
+	if len(keys) != 5 {
+		t.Fatalf("tree.InOrder() = %v; expected 5 keys\n", keys)
+	}
+	if f := float64(keys[0].(goavl.Float64)); !math.IsNaN(f) {
+		t.Errorf("keys[0] = %v; expected NaN\n", f)
+	}
+	if f := float64(keys[2].(goavl.Float64)); f != 0 || !math.Signbit(f) {
+		t.Errorf("keys[2] = %v; expected -0\n", f)
+	}
+	if f := float64(keys[3].(goavl.Float64)); f != 0 || math.Signbit(f) {
+		t.Errorf("keys[3] = %v; expected +0\n", f)
+	}
+}
+
+func TestInsertHelpers(t *testing.T) {
+	tree := goavl.NewTree()
+	if err := goavl.InsertInts(tree, 3, 1, 2); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if err := goavl.InsertInts(tree, 4, 1, 5); err == nil {
+		t.Errorf("\tExpected an error for inserting 1 again!\n")
+	}
+	if tree.Size() != 5 {
+		t.Errorf("tree.Size() = %d; expected 5 (insertion should go on after a failure)\n", tree.Size())
+	}
+
+	tree = goavl.NewTree()
+	if err := goavl.InsertStrings(tree, "b", "a", "c"); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if min, _ := tree.Min(); min != goavl.String("a") {
+		t.Errorf("tree.Min() = %v; expected a\n", min)
+	}
+
+	tree = goavl.NewTree()
+	if err := goavl.InsertDurations(tree, time.Second, time.Millisecond); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if max, _ := tree.Max(); max.(goavl.Duration).String() != "1s" {
+		t.Errorf("tree.Max() = %v; expected 1s\n", max)
+	}
+}
+
+func TestInsertAll(t *testing.T) {
+	type point struct{ x, y int }
+	tree := goavl.NewTree()
+	conv := func(p point) goavl.Item { return goavl.Int(p.x*10 + p.y) }
+	err := goavl.InsertAll(tree, conv, point{1, 2}, point{0, 3}, point{1, 2}, point{2, 0})
+	if err == nil {
+		t.Errorf("\tExpected an error for inserting {1 2} again!\n")
+	} else {
+		t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+	}
+	if tree.Size() != 3 {
+		t.Errorf("tree.Size() = %d; expected 3 (insertion should go on after a failure)\n", tree.Size())
+	}
+	if max, _ := tree.Max(); max != goavl.Int(20) {
+		t.Errorf("tree.Max() = %v; expected 20\n", max)
+	}
+}
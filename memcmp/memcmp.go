@@ -0,0 +1,300 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+// Package memcmp implements an order-preserving ("memcomparable") binary
+// encoding of keys: the byte-wise order of encoded keys, as by bytes.Compare,
+// matches the logical order of the values they were encoded from.
+//
+// A key is a sequence of one or more values, each of which is encoded along
+// with its type, so that keys of heterogeneous types may be stored in the same
+// goavl tree, as goavl.Bytes. Keys are ordered lexicographically by their
+// values, a key being less than any longer key that it is a prefix of. Values
+// of different types are ordered by their type, in the following order:
+//
+//	bool < int < uint < float < string < []byte < time.Time < tuple
+//
+// i.e. integers are not ordered numerically against floats. Values of the
+// same type are ordered naturally; in particular, floats are ordered as
+// goavl.Float64 (NaN < -Inf < -0 < +0 < +Inf), all NaNs being encoded alike,
+// regardless of their sign and payload.
+//
+// The supported Go types are bool, all signed and unsigned integer types,
+// float32, float64, string, []byte, time.Time, time.Duration (encoded as an
+// int) and []interface{} for nested tuples, as well as the respective Item
+// types of goavl (Int, Int64, Uint64, Float64, String, Bytes, Time, Duration
+// and Tuple).
+package memcmp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ckatsak/goavl"
+)
+
+// Type tags, which precede each encoded value. Their order defines the order
+// of values of different types.
+const (
+	tagEnd    = 0x00 // terminates tuples
+	tagFalse  = 0x10
+	tagTrue   = 0x11
+	tagInt    = 0x20
+	tagUint   = 0x21
+	tagFloat  = 0x30
+	tagString = 0x40
+	tagBytes  = 0x41
+	tagTime   = 0x50
+	tagTuple  = 0x60
+)
+
+// Escaping of zero bytes in strings and byte slices.
+const (
+	escZero = 0xff // 0x00 0xff encodes a 0x00 byte
+	escEnd  = 0x01 // 0x00 0x01 terminates the string
+)
+
+// Encode encodes the given values into a memcomparable key.
+func Encode(vals ...interface{}) ([]byte, error) {
+	return Append(nil, vals...)
+}
+
+// Append appends the memcomparable encoding of the given values to b.
+func Append(b []byte, vals ...interface{}) ([]byte, error) {
+	var err error
+	for _, v := range vals {
+		if b, err = appendValue(b, v); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// appendValue appends the encoding of v, preceded by its tag, to b.
+func appendValue(b []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return append(b, tagTrue), nil
+		}
+		return append(b, tagFalse), nil
+	case int:
+		return appendInt(b, int64(v)), nil
+	case int8:
+		return appendInt(b, int64(v)), nil
+	case int16:
+		return appendInt(b, int64(v)), nil
+	case int32:
+		return appendInt(b, int64(v)), nil
+	case int64:
+		return appendInt(b, v), nil
+	case time.Duration:
+		return appendInt(b, int64(v)), nil
+	case goavl.Int:
+		return appendInt(b, int64(v)), nil
+	case goavl.Int64:
+		return appendInt(b, int64(v)), nil
+	case goavl.Duration:
+		return appendInt(b, int64(v)), nil
+	case uint:
+		return appendUint(b, uint64(v)), nil
+	case uint8:
+		return appendUint(b, uint64(v)), nil
+	case uint16:
+		return appendUint(b, uint64(v)), nil
+	case uint32:
+		return appendUint(b, uint64(v)), nil
+	case uint64:
+		return appendUint(b, v), nil
+	case goavl.Uint64:
+		return appendUint(b, uint64(v)), nil
+	case float32:
+		return appendFloat(b, float64(v)), nil
+	case float64:
+		return appendFloat(b, v), nil
+	case goavl.Float64:
+		return appendFloat(b, float64(v)), nil
+	case string:
+		return appendEscaped(append(b, tagString), v), nil
+	case goavl.String:
+		return appendEscaped(append(b, tagString), string(v)), nil
+	case []byte:
+		return appendEscaped(append(b, tagBytes), string(v)), nil
+	case goavl.Bytes:
+		return appendEscaped(append(b, tagBytes), string(v)), nil
+	case time.Time:
+		return appendTime(b, v), nil
+	case goavl.Time:
+		return appendTime(b, v.Time), nil
+	case []interface{}:
+		return appendTuple(b, len(v), func(i int) interface{} { return v[i] })
+	case goavl.Tuple:
+		return appendTuple(b, len(v), func(i int) interface{} { return v[i] })
+	}
+	return nil, fmt.Errorf("Unsupported type of value: %T", v)
+}
+
+func appendInt(b []byte, v int64) []byte {
+	return binary.BigEndian.AppendUint64(append(b, tagInt), uint64(v)^(1<<63))
+}
+
+func appendUint(b []byte, v uint64) []byte {
+	return binary.BigEndian.AppendUint64(append(b, tagUint), v)
+}
+
+func appendFloat(b []byte, v float64) []byte {
+	if math.IsNaN(v) {
+		// Below the encoding of -Inf, which is 0x000fffffffffffff.
+		return binary.BigEndian.AppendUint64(append(b, tagFloat), 0)
+	}
+	bits := math.Float64bits(v)
+	if bits&(1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits |= 1 << 63
+	}
+	return binary.BigEndian.AppendUint64(append(b, tagFloat), bits)
+}
+
+func appendEscaped(b []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		if s[i] == 0 {
+			b = append(b, 0, escZero)
+		} else {
+			b = append(b, s[i])
+		}
+	}
+	return append(b, 0, escEnd)
+}
+
+func appendTime(b []byte, t time.Time) []byte {
+	b = binary.BigEndian.AppendUint64(append(b, tagTime), uint64(t.Unix())^(1<<63))
+	return binary.BigEndian.AppendUint32(b, uint32(t.Nanosecond()))
+}
+
+func appendTuple(b []byte, n int, elem func(int) interface{}) ([]byte, error) {
+	var err error
+	b = append(b, tagTuple)
+	for i := 0; i < n; i++ {
+		if b, err = appendValue(b, elem(i)); err != nil {
+			return nil, err
+		}
+	}
+	return append(b, tagEnd), nil
+}
+
+// Decode decodes a memcomparable key into its values. Integers are decoded
+// as int64, unsigned integers as uint64, floats as float64, strings as
+// string, byte slices as []byte, times as time.Time in UTC, and tuples as
+// []interface{}.
+func Decode(b []byte) ([]interface{}, error) {
+	var vals []interface{}
+	for len(b) > 0 {
+		v, rest, err := decodeValue(b)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, v)
+		b = rest
+	}
+	return vals, nil
+}
+
+// decodeValue decodes the first value of b, and returns it along with the
+// rest of b.
+func decodeValue(b []byte) (interface{}, []byte, error) {
+	tag, b := b[0], b[1:]
+	switch tag {
+	case tagFalse:
+		return false, b, nil
+	case tagTrue:
+		return true, b, nil
+	case tagInt:
+		if len(b) < 8 {
+			return nil, nil, errTruncated
+		}
+		return int64(binary.BigEndian.Uint64(b) ^ (1 << 63)), b[8:], nil
+	case tagUint:
+		if len(b) < 8 {
+			return nil, nil, errTruncated
+		}
+		return binary.BigEndian.Uint64(b), b[8:], nil
+	case tagFloat:
+		if len(b) < 8 {
+			return nil, nil, errTruncated
+		}
+		bits := binary.BigEndian.Uint64(b)
+		if bits&(1<<63) != 0 {
+			bits &^= 1 << 63
+		} else {
+			bits = ^bits
+		}
+		return math.Float64frombits(bits), b[8:], nil
+	case tagString, tagBytes:
+		s, rest, err := decodeEscaped(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		if tag == tagString {
+			return string(s), rest, nil
+		}
+		return s, rest, nil
+	case tagTime:
+		if len(b) < 12 {
+			return nil, nil, errTruncated
+		}
+		sec := int64(binary.BigEndian.Uint64(b) ^ (1 << 63))
+		nsec := int64(binary.BigEndian.Uint32(b[8:]))
+		return time.Unix(sec, nsec).UTC(), b[12:], nil
+	case tagTuple:
+		tuple := []interface{}{}
+		for {
+			if len(b) == 0 {
+				return nil, nil, errTruncated
+			}
+			if b[0] == tagEnd {
+				return tuple, b[1:], nil
+			}
+			v, rest, err := decodeValue(b)
+			if err != nil {
+				return nil, nil, err
+			}
+			tuple = append(tuple, v)
+			b = rest
+		}
+	}
+	return nil, nil, fmt.Errorf("Invalid type tag: %#x", tag)
+}
+
+// decodeEscaped decodes an escaped string from the start of b, and returns it
+// along with the rest of b.
+func decodeEscaped(b []byte) ([]byte, []byte, error) {
+	s := []byte{}
+	for i := 0; i < len(b); i++ {
+		if b[i] != 0 {
+			s = append(s, b[i])
+			continue
+		}
+		if i+1 == len(b) {
+			break
+		}
+		switch b[i+1] {
+		case escZero:
+			s = append(s, 0)
+			i++
+		case escEnd:
+			return s, b[i+2:], nil
+		default:
+			return nil, nil, fmt.Errorf("Invalid escape sequence: 0x00 %#x", b[i+1])
+		}
+	}
+	return nil, nil, errTruncated
+}
+
+var errTruncated = fmt.Errorf("Truncated key")
@@ -0,0 +1,223 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package memcmp
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ckatsak/goavl"
+)
+
+// TestOrder checks that each key encodes to bytes that sort strictly after
+// those of the previous one.
+func TestOrder(t *testing.T) {
+	epoch := time.Unix(0, 0)
+	keys := [][]interface{}{
+		{false},
+		{false, int64(math.MinInt64)},
+		{true},
+		{math.MinInt64},
+		{-1},
+		{0},
+		{0, "a"},
+		{1},
+		{math.MaxInt64},
+		{uint64(0)},
+		{uint64(math.MaxUint64)},
+		{math.NaN()},
+		{math.Inf(-1)},
+		{-1.5},
+		{math.Copysign(0, -1)},
+		{0.0},
+		{math.SmallestNonzeroFloat64},
+		{math.Inf(1)},
+		{""},
+		{"", ""},
+		{"\x00"},
+		{"\x00\x00"},
+		{"\x00\x01"},
+		{"a"},
+		{"a", "b"},
+		{"a\x00"},
+		{"ab"},
+		{"b"},
+		{[]byte{}},
+		{[]byte{0xff}},
+		{epoch.Add(-time.Nanosecond)},
+		{epoch},
+		{epoch.Add(time.Nanosecond)},
+		{epoch.Add(time.Second)},
+		{[]interface{}{}},
+		{[]interface{}{}, 0},
+		{[]interface{}{0}},
+		{[]interface{}{0, "a"}},
+		{[]interface{}{1}},
+	}
+	var prev []byte
+	for i, vals := range keys {
+		b, err := Encode(vals...)
+		if err != nil {
+			t.Fatalf("Encode(%v): %v\n", vals, err)
+		}
+		if i > 0 && bytes.Compare(prev, b) >= 0 {
+			t.Errorf("Encode(%v) = %x does not sort after Encode(%v) = %x\n", vals, b, keys[i-1], prev)
+		}
+		prev = b
+	}
+}
+
+// TestFloat64Order checks that the order of encoded goavl.Float64s matches
+// their Less and Equal methods, for all pairs of a sample of values.
+func TestFloat64Order(t *testing.T) {
+	vals := []float64{
+		math.NaN(),
+		math.Copysign(math.NaN(), -1),
+		math.Float64frombits(0x7ff0000000000001),
+		math.Float64frombits(0xfff8000000000123),
+		math.Inf(-1),
+		math.Inf(1),
+		math.Copysign(0, -1),
+		0,
+		math.SmallestNonzeroFloat64,
+		-math.MaxFloat64,
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		vals = append(vals, r.NormFloat64()*math.Pow(10, float64(r.Intn(40)-20)))
+	}
+	for _, a := range vals {
+		ea, _ := Encode(goavl.Float64(a))
+		for _, b := range vals {
+			eb, _ := Encode(goavl.Float64(b))
+			expected := 1
+			switch {
+			case goavl.Float64(a).Less(goavl.Float64(b)):
+				expected = -1
+			case goavl.Float64(a).Equal(goavl.Float64(b)):
+				expected = 0
+			}
+			if c := bytes.Compare(ea, eb); c != expected {
+				t.Errorf("bytes.Compare(Encode(%v), Encode(%v)) = %d; expected %d\n", a, b, c, expected)
+			}
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	now := time.Now().UTC()
+	for _, vals := range [][]interface{}{
+		{true, false},
+		{int64(-42), uint64(42), 4.2, "forty\x00two", []byte{4, 0, 2}, now},
+		{[]interface{}{int64(1), []interface{}{"nested"}, []interface{}{}}, "after"},
+	} {
+		b, err := Encode(vals...)
+		if err != nil {
+			t.Fatalf("Encode(%v): %v\n", vals, err)
+		}
+		got, err := Decode(b)
+		if err != nil {
+			t.Fatalf("Decode(%x): %v\n", b, err)
+		}
+		if !reflect.DeepEqual(got, vals) {
+			t.Errorf("Decode(Encode(%v)) = %v\n", vals, got)
+		}
+	}
+
+	// Types are normalized.
+	b, _ := Encode(int8(-1), uint16(1), float32(0.5), goavl.Int(2), goavl.String("s"), time.Second,
+		goavl.Tuple{goavl.Int(3)})
+	got, err := Decode(b)
+	if err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	expected := []interface{}{int64(-1), uint64(1), 0.5, int64(2), "s", int64(time.Second), []interface{}{int64(3)}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Decode() = %v; expected %v\n", got, expected)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := Encode(struct{}{}); err == nil {
+		t.Errorf("\tExpected an error for an unsupported type!\n")
+	}
+	if _, err := Encode([]interface{}{1, struct{}{}}); err == nil {
+		t.Errorf("\tExpected an error for an unsupported type in a tuple!\n")
+	}
+	for _, b := range [][]byte{
+		{0x99},
+		{tagInt, 0},
+		{tagString, 'a'},
+		{tagString, 'a', 0},
+		{tagString, 'a', 0, 0x42},
+		{tagTuple, tagTrue},
+		{tagTime, 0, 0, 0, 0, 0, 0, 0, 0},
+	} {
+		if _, err := Decode(b); err == nil {
+			t.Errorf("Decode(%x): expected an error!\n", b)
+		} else {
+			t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+		}
+	}
+}
+
+func TestTree(t *testing.T) {
+	tree := NewTree()
+	for _, vals := range [][]interface{}{
+		{"acme", 2, "x"},
+		{"acme", 1, "y"},
+		{"acme", 10, "z"},
+		{"globex", 1, "w"},
+		{42},
+		{true},
+	} {
+		if err := tree.Insert(vals...); err != nil {
+			t.Errorf("\t%v\n", err)
+		}
+	}
+	if err := tree.Insert("acme", 1, "y"); err == nil {
+		t.Errorf("\tExpected an error for a duplicate key!\n")
+	}
+	if err := tree.Insert(struct{}{}); err == nil {
+		t.Errorf("\tExpected an error for an unsupported type!\n")
+	}
+	if tree.Size() != 6 {
+		t.Errorf("tree.Size() = %d; expected 6\n", tree.Size())
+	}
+
+	if min, err := tree.Min(); err != nil || !reflect.DeepEqual(min, []interface{}{true}) {
+		t.Errorf("tree.Min() = %v, %v; expected [true]\n", min, err)
+	}
+	if max, err := tree.Max(); err != nil || !reflect.DeepEqual(max, []interface{}{"globex", int64(1), "w"}) {
+		t.Errorf("tree.Max() = %v, %v; expected [globex 1 w]\n", max, err)
+	}
+
+	var ids []interface{}
+	err := tree.ScanPrefix(func(vals []interface{}) bool {
+		ids = append(ids, vals[1])
+		return true
+	}, "acme")
+	if err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	if !reflect.DeepEqual(ids, []interface{}{int64(1), int64(2), int64(10)}) {
+		t.Errorf("ScanPrefix(acme) visited %v; expected [1 2 10]\n", ids)
+	}
+
+	if err := tree.Delete(42); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if ok, _ := tree.Contains(42); ok {
+		t.Errorf("tree.Contains(42) = true after deleting it\n")
+	}
+}
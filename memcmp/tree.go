@@ -0,0 +1,113 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package memcmp
+
+import (
+	"bytes"
+
+	"github.com/ckatsak/goavl"
+)
+
+// Tree is a goavl tree whose keys are sequences of values of arbitrary
+// supported types, stored encoded as goavl.Bytes.
+type Tree struct {
+	tree *goavl.Tree
+}
+
+// NewTree creates a new empty Tree.
+func NewTree() *Tree {
+	return &Tree{tree: goavl.NewTree()}
+}
+
+// Key encodes the given values into a goavl.Bytes key.
+func Key(vals ...interface{}) (goavl.Bytes, error) {
+	b, err := Encode(vals...)
+	return goavl.Bytes(b), err
+}
+
+// Tree returns the underlying goavl tree, whose keys are goavl.Bytes.
+func (t *Tree) Tree() *goavl.Tree {
+	return t.tree
+}
+
+// Size returns the current number of keys in the Tree.
+func (t *Tree) Size() int {
+	return t.tree.Size()
+}
+
+// Insert inserts the key composed of the given values into the Tree. It fails
+// if the values cannot be encoded or if the key already exists in the Tree.
+func (t *Tree) Insert(vals ...interface{}) error {
+	key, err := Key(vals...)
+	if err != nil {
+		return err
+	}
+	return t.tree.Insert(key)
+}
+
+// Delete removes the key composed of the given values from the Tree. It fails
+// if the values cannot be encoded or if the key doesn't exist in the Tree.
+func (t *Tree) Delete(vals ...interface{}) error {
+	key, err := Key(vals...)
+	if err != nil {
+		return err
+	}
+	return t.tree.Delete(key)
+}
+
+// Contains reports whether the key composed of the given values exists in the
+// Tree. It fails if the values cannot be encoded.
+func (t *Tree) Contains(vals ...interface{}) (bool, error) {
+	key, err := Key(vals...)
+	if err != nil {
+		return false, err
+	}
+	return t.tree.Contains(key), nil
+}
+
+// Min returns the decoded values of the minimum key in the Tree.
+func (t *Tree) Min() ([]interface{}, error) {
+	key, err := t.tree.Min()
+	if err != nil {
+		return nil, err
+	}
+	return Decode(key.(goavl.Bytes))
+}
+
+// Max returns the decoded values of the maximum key in the Tree.
+func (t *Tree) Max() ([]interface{}, error) {
+	key, err := t.tree.Max()
+	if err != nil {
+		return nil, err
+	}
+	return Decode(key.(goavl.Bytes))
+}
+
+// ScanPrefix calls visit, in ascending order, with the decoded values of each
+// key in the Tree whose leading values are equal to the given ones, until
+// visit returns false. It fails if the values cannot be encoded, or if a key
+// cannot be decoded.
+func (t *Tree) ScanPrefix(visit func([]interface{}) bool, prefix ...interface{}) error {
+	p, err := Key(prefix...)
+	if err != nil {
+		return err
+	}
+	t.tree.AscendRange(p, nil, func(item goavl.Item) bool {
+		key := item.(goavl.Bytes)
+		if !bytes.HasPrefix(key, p) {
+			return false
+		}
+		var vals []interface{}
+		if vals, err = Decode(key); err != nil {
+			return false
+		}
+		return visit(vals)
+	})
+	return err
+}
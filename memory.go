@@ -0,0 +1,92 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Sizer is implemented by Items that can report the number of bytes they
+// reference, beyond the interface value stored in each treeNode, e.g. the
+// contents of a string. It is used by Tree.MemoryUsage.
+type Sizer interface {
+	ByteSize() int
+}
+
+// The sizes of the structures that make up an AVL tree, in bytes.
+const (
+	treeSize = int(unsafe.Sizeof(Tree{}))
+	nodeSize = int(unsafe.Sizeof(treeNode{}))
+	itemSize = int(unsafe.Sizeof(Item(nil)))
+)
+
+// keySize returns the number of bytes that key references, if it implements
+// Sizer, or 0 otherwise.
+func keySize(key Item) int {
+	if s, ok := key.(Sizer); ok {
+		return s.ByteSize()
+	}
+	return 0
+}
+
+// MemoryUsage returns an estimate of the number of bytes used by the AVL tree:
+// the Tree itself, one treeNode per key, and the bytes referenced by the keys
+// that implement Sizer. Keys that do not implement Sizer are accounted for
+// only by the interface values in the treeNodes. Memory allocator overheads
+// are not taken into account.
+//
+// Keys are accounted for as they are inserted into and removed from the tree,
+// by the sizes of the keys stored in it.
+func (t *Tree) MemoryUsage() int {
+	return treeSize + t.size*nodeSize + t.keyBytes
+}
+
+// SetMemoryLimit sets a soft memory limit for the AVL tree, in bytes, as
+// estimated by MemoryUsage: Insert, as well as Txn.Insert, fails with a
+// *MemoryLimitError if the key would make the tree exceed it. Other operations
+// that add keys, such as ApplyBatch, are not limited. A limit of 0 or less
+// removes the limit.
+func (t *Tree) SetMemoryLimit(limit int) {
+	t.memLimit = max(limit, 0)
+}
+
+// MemoryLimit returns the soft memory limit of the AVL tree, or 0 if there is
+// none.
+func (t *Tree) MemoryLimit() int {
+	return t.memLimit
+}
+
+// MemoryLimitError is the error returned by Insert and Txn.Insert if inserting
+// a key would make an AVL tree exceed its memory limit.
+type MemoryLimitError struct {
+	Key   Item // the key that was not inserted
+	Usage int  // the memory usage of the tree, had the key been inserted
+	Limit int  // the memory limit of the tree
+}
+
+func (e *MemoryLimitError) Error() string {
+	return fmt.Sprintf("Memory limit exceeded: inserting %v would use %d bytes, over the limit of %d",
+		e.Key, e.Usage, e.Limit)
+}
+
+// checkMemoryLimit returns a *MemoryLimitError if inserting key into the AVL
+// subtree rooted with root, which holds size keys of keyBytes total size,
+// would exceed limit, or nil otherwise. Keys that are already in the subtree
+// are left for the insertion to report.
+func checkMemoryLimit(root *treeNode, size, keyBytes, limit int, key Item) error {
+	if limit == 0 {
+		return nil
+	}
+	usage := treeSize + (size+1)*nodeSize + keyBytes + keySize(key)
+	if usage <= limit || root.subtreeSearch(key) != nil {
+		return nil
+	}
+	return &MemoryLimitError{Key: key, Usage: usage, Limit: limit}
+}
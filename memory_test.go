@@ -0,0 +1,130 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// verifyMemoryUsage checks the MemoryUsage of tree against a full traversal.
+func verifyMemoryUsage(t *testing.T, tree *Tree) {
+	t.Helper()
+	size, keyBytes := 0, 0
+	tree.AscendRange(nil, nil, func(key Item) bool {
+		size++
+		keyBytes += keySize(key)
+		return true
+	})
+	expected := treeSize + size*nodeSize + keyBytes
+	if usage := tree.MemoryUsage(); usage != expected {
+		t.Fatalf("MemoryUsage() = %d; expected %d\n", usage, expected)
+	}
+}
+
+func TestMemoryUsage(t *testing.T) {
+	tree := NewTree()
+	if usage := tree.MemoryUsage(); usage != treeSize {
+		t.Errorf("MemoryUsage() of an empty tree = %d; expected %d\n", usage, treeSize)
+	}
+	for i := 0; i < 100; i++ {
+		tree.Insert(String(fmt.Sprint(i)))
+	}
+	verifyMemoryUsage(t, tree)
+	if usage, expected := tree.MemoryUsage(), treeSize+100*nodeSize+10+2*90; usage != expected {
+		t.Errorf("MemoryUsage() = %d; expected %d\n", usage, expected)
+	}
+
+	// All mutation paths keep the estimate up to date.
+	tree.Delete(String("5"))
+	tree.PopMin()
+	tree.PopMax()
+	verifyMemoryUsage(t, tree)
+	extracted := tree.ExtractRange(String("2"), String("4"))
+	verifyMemoryUsage(t, tree)
+	verifyMemoryUsage(t, extracted)
+	tree.DeleteFunc(func(key Item) bool { return len(key.(String)) == 1 })
+	verifyMemoryUsage(t, tree)
+	tree.ApplyBatch([]Op{{OpInsert, String("1")}, {OpDelete, String("50")}, {OpInsert, String("500")}})
+	verifyMemoryUsage(t, tree)
+	verifyMemoryUsage(t, Filter(tree, func(key Item) bool { return key.(String) < "7" }))
+
+	tuples := NewTree()
+	tuples.Insert(Tuple{String("abc"), Int(1)})
+	verifyMemoryUsage(t, tuples)
+	if usage, expected := tuples.MemoryUsage(), treeSize+nodeSize+2*itemSize+3; usage != expected {
+		t.Errorf("MemoryUsage() = %d; expected %d\n", usage, expected)
+	}
+}
+
+func TestMemoryLimit(t *testing.T) {
+	tree := NewTree()
+	tree.SetMemoryLimit(treeSize + 2*nodeSize + 10)
+	if err := tree.Insert(String("12345")); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if err := tree.Insert(String("12345")); err == nil || errors.As(err, new(*MemoryLimitError)) {
+		t.Errorf("Insert() of a duplicate key = %v; expected a duplicate key error\n", err)
+	}
+	err := tree.Insert(String("123456"))
+	var limitErr *MemoryLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("Insert() = %v; expected a *MemoryLimitError\n", err)
+	}
+	t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+	if limitErr.Key != String("123456") || limitErr.Usage != treeSize+2*nodeSize+11 {
+		t.Errorf("unexpected error fields: %+v\n", limitErr)
+	}
+	if err := tree.Insert(String("1234")); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if tree.Size() != 2 {
+		t.Errorf("Size() = %d; expected 2\n", tree.Size())
+	}
+
+	tree.SetMemoryLimit(0)
+	if err := tree.Insert(String("123456")); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+}
+
+// paddedInteger is an Integer that reports pad bytes, which do not affect its
+// order, so that equal keys may differ in size.
+type paddedInteger struct {
+	i   Integer
+	pad int
+}
+
+func (p paddedInteger) Equal(to Item) bool  { return p.i == to.(paddedInteger).i }
+func (p paddedInteger) Less(than Item) bool { return p.i < than.(paddedInteger).i }
+func (p paddedInteger) ByteSize() int       { return p.pad }
+
+func TestMemoryUsageDeleteEqual(t *testing.T) {
+	tree := NewTree()
+	for i := 0; i < 20; i++ {
+		tree.Insert(paddedInteger{Integer(i), 100})
+	}
+
+	// Deleting by an equal key of another size frees the size of the stored one.
+	if err := tree.Delete(paddedInteger{Integer(3), 1}); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	verifyMemoryUsage(t, tree)
+	tree.ApplyBatch([]Op{{OpDelete, paddedInteger{Integer(5), 0}}, {OpInsert, paddedInteger{Integer(5), 7}}})
+	tree.ApplyBatch([]Op{{OpDelete, paddedInteger{Integer(8), 0}}})
+	verifyMemoryUsage(t, tree)
+	txn := tree.Begin()
+	txn.Delete(paddedInteger{Integer(9), 0})
+	txn.Commit()
+	verifyMemoryUsage(t, tree)
+	if usage, expected := tree.MemoryUsage(), treeSize+17*nodeSize+16*100+7; usage != expected {
+		t.Errorf("MemoryUsage() = %d; expected %d\n", usage, expected)
+	}
+}
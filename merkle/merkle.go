@@ -0,0 +1,131 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+// Package merkle implements content hashes over ranges of keys of goavl
+// trees, and a protocol that uses them to find the differences between the
+// keys of two trees, typically on different hosts, by exchanging hashes of
+// ranges rather than the keys themselves.
+//
+// The hash of a range depends only on the keys in it, and not on the shape of
+// the tree, which depends on the order the keys were inserted in. Each key is
+// hashed on its own, as encoded by a goavl.KeyCodec, and the hashes of the
+// keys are added up, modulo 2^256; the hash of the range is the hash of their
+// sum along with their number.
+//
+// Since sums can be combined, Index installs a goavl.Augmenter in a tree that
+// keeps the sum of the hashes of the keys in each of its subtrees, so that
+// the hash of any range is computed out of O(log n) of them, instead of
+// hashing every key in the range.
+package merkle
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"reflect"
+
+	"github.com/ckatsak/goavl"
+)
+
+// Hash is the hash of a range of keys.
+type Hash [sha256.Size]byte
+
+// summary is the sum of the hashes of the keys in a range, along with their
+// number, or the error of encoding one of them.
+type summary struct {
+	sum   [sha256.Size]byte
+	count int
+	err   error
+}
+
+// digest returns the summary of key, encoded with codec.
+func digest(codec goavl.KeyCodec, key goavl.Item) summary {
+	raw, err := codec.Encode(key)
+	if err != nil {
+		return summary{err: err}
+	}
+	return summary{sum: sha256.Sum256(raw), count: 1}
+}
+
+// add adds the keys that o summarizes to s.
+func (s *summary) add(o *summary) {
+	if s.err == nil {
+		s.err = o.err
+	}
+	carry := 0
+	for i := len(s.sum) - 1; i >= 0; i-- {
+		x := int(s.sum[i]) + int(o.sum[i]) + carry
+		s.sum[i], carry = byte(x), x>>8
+	}
+	s.count += o.count
+}
+
+// hash returns the Hash of the range that s summarizes.
+func (s *summary) hash() Hash {
+	b := binary.BigEndian.AppendUint64(make([]byte, 0, 8+len(s.sum)), uint64(s.count))
+	return sha256.Sum256(append(b, s.sum[:]...))
+}
+
+// hasher is the goavl.Augmenter that Index installs, whose aggregates are
+// *summaries of the keys, encoded with codec.
+type hasher struct {
+	codec goavl.KeyCodec
+}
+
+func (h *hasher) Value(key goavl.Item) interface{} {
+	s := digest(h.codec, key)
+	return &s
+}
+
+func (h *hasher) Combine(a, b interface{}) interface{} {
+	s := *a.(*summary)
+	s.add(b.(*summary))
+	return &s
+}
+
+// Index makes t keep the summary of the keys in each of its subtrees, encoded
+// with codec, so that RangeHash, Respond and Reconcile take O(log n) time to
+// hash a range of keys of t with codec. It takes O(n) time, and it replaces
+// any other goavl.Augmenter of t.
+func Index(t *goavl.Tree, codec goavl.KeyCodec) {
+	t.SetAugmenter(&hasher{codec: codec})
+}
+
+// summarize returns the summary of the keys of t in [lo, hi), encoded with
+// codec, along with an error value, which is non-nil if a key cannot be
+// encoded. A nil lo or hi leaves the range unbounded on that side. It takes
+// O(log n) time if t is indexed with codec, or else time linear in the number
+// of keys in the range.
+func summarize(t *goavl.Tree, codec goavl.KeyCodec, lo, hi goavl.Item) (summary, error) {
+	var s summary
+	if h, ok := t.Augmenter().(*hasher); ok && reflect.DeepEqual(h.codec, codec) {
+		if agg := t.Aggregate(lo, hi); agg != nil {
+			s = *agg.(*summary)
+		}
+		return s, s.err
+	}
+	t.AscendRange(lo, hi, func(key goavl.Item) bool {
+		d := digest(codec, key)
+		s.add(&d)
+		return s.err == nil
+	})
+	return s, s.err
+}
+
+// RangeHash returns the Hash of the keys of t that are greater than or equal
+// to lo and less than hi, encoded with codec, along with their number and an
+// error value, which is non-nil if a key cannot be encoded. A nil lo or hi
+// leaves the range unbounded on that side. It takes O(log n) time if t is
+// indexed with codec (see Index), or else time linear in the number of keys
+// in the range.
+func RangeHash(t *goavl.Tree, codec goavl.KeyCodec, lo, hi goavl.Item) (Hash, int, error) {
+	s, err := summarize(t, codec, lo, hi)
+	if err != nil {
+		return Hash{}, 0, err
+	}
+	return s.hash(), s.count, nil
+}
@@ -0,0 +1,193 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package merkle
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/ckatsak/goavl"
+	"github.com/ckatsak/goavl/avltest"
+)
+
+// rangeHash hashes the keys of t in [lo, hi) one by one.
+func rangeHash(t *goavl.Tree, lo, hi goavl.Item) (Hash, int) {
+	var s summary
+	t.AscendRange(lo, hi, func(key goavl.Item) bool {
+		d := digest(goavl.IntCodec, key)
+		s.add(&d)
+		return true
+	})
+	return s.hash(), s.count
+}
+
+func TestIndex(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := goavl.NewTree()
+	Index(tree, goavl.IntCodec)
+	avltest.Run(t, tree, r, 2000, func(r *rand.Rand) goavl.Item {
+		return goavl.Int(r.Intn(500))
+	})
+
+	// The summaries of the subtrees must be kept up to date by rotations.
+	for i := 0; i < 100; i++ {
+		lo, hi := goavl.Int(r.Intn(600)-50), goavl.Int(r.Intn(600)-50)
+		h, n, err := RangeHash(tree, goavl.IntCodec, lo, hi)
+		if err != nil {
+			t.Fatalf("\t%v\n", err)
+		}
+		if eh, en := rangeHash(tree, lo, hi); h != eh || n != en {
+			t.Fatalf("RangeHash(%v, %v) = %x, %d; expected %x, %d\n", lo, hi, h, n, eh, en)
+		}
+	}
+
+	// Keys that cannot be encoded are reported, whether indexed or not.
+	if _, _, err := RangeHash(tree, goavl.StringCodec, nil, nil); err == nil {
+		t.Errorf("\tExpected an error for keys that cannot be encoded!\n")
+	}
+	Index(tree, goavl.StringCodec)
+	if _, _, err := RangeHash(tree, goavl.StringCodec, nil, nil); err == nil {
+		t.Errorf("\tExpected an error for indexed keys that cannot be encoded!\n")
+	} else {
+		t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+	}
+}
+
+func TestRangeHash(t *testing.T) {
+	a, b := goavl.NewTree(), goavl.NewTree()
+	for i := 0; i < 1000; i++ {
+		a.Insert(goavl.Int(i))
+	}
+	for _, r := range rand.Perm(1000) {
+		b.Insert(goavl.Int(r))
+	}
+	c := goavl.Filter(a, func(goavl.Item) bool { return true })
+	Index(c, goavl.IntCodec)
+
+	for _, r := range [][2]goavl.Item{{nil, nil}, {goavl.Int(10), goavl.Int(500)}, {nil, goavl.Int(3)}, {goavl.Int(999), nil}} {
+		ha, na, err := RangeHash(a, goavl.IntCodec, r[0], r[1])
+		if err != nil {
+			t.Fatalf("\t%v\n", err)
+		}
+		hb, nb, _ := RangeHash(b, goavl.IntCodec, r[0], r[1])
+		if ha != hb || na != nb {
+			t.Errorf("RangeHash(%v, %v) depends on the shape of the tree\n", r[0], r[1])
+		}
+		if hc, nc, _ := RangeHash(c, goavl.IntCodec, r[0], r[1]); ha != hc || na != nc {
+			t.Errorf("RangeHash(%v, %v) of an indexed tree differs from hashing the keys one by one\n", r[0], r[1])
+		}
+	}
+
+	whole, _, _ := RangeHash(a, goavl.IntCodec, nil, nil)
+	b.Delete(goavl.Int(500))
+	if h, n, _ := RangeHash(b, goavl.IntCodec, nil, nil); h == whole || n != 999 {
+		t.Errorf("RangeHash() did not change after a deletion\n")
+	}
+	empty1, _, _ := RangeHash(a, goavl.IntCodec, goavl.Int(-5), goavl.Int(-1))
+	empty2, _, _ := RangeHash(c, goavl.IntCodec, goavl.Int(2000), nil)
+	if empty1 != empty2 {
+		t.Errorf("empty ranges have different hashes\n")
+	}
+
+	if _, _, err := RangeHash(a, goavl.StringCodec, nil, nil); err == nil {
+		t.Errorf("\tExpected an error for keys that cannot be encoded!\n")
+	}
+}
+
+// reconcile runs a Reconcile session between local and remote over a pipe.
+func reconcile(t *testing.T, local, remote *goavl.Tree) *Differences {
+	t.Helper()
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Respond(c2, remote, goavl.IntCodec)
+	}()
+	diff, err := Reconcile(c1, local, goavl.IntCodec)
+	if err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	return diff
+}
+
+// sorted returns keys, sorted and formatted.
+func sorted(keys []goavl.Item) string {
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Less(keys[j]) })
+	return fmt.Sprint(keys)
+}
+
+func TestReconcile(t *testing.T) {
+	for iter := 0; iter < 20; iter++ {
+		local, remote := goavl.NewTree(), goavl.NewTree()
+		var onlyLocal, onlyRemote []goavl.Item
+		for i := 0; i < 5000; i++ {
+			key := goavl.Int(i)
+			switch rand.Intn(200) {
+			case 0:
+				local.Insert(key)
+				onlyLocal = append(onlyLocal, key)
+			case 1:
+				remote.Insert(key)
+				onlyRemote = append(onlyRemote, key)
+			default:
+				local.Insert(key)
+				remote.Insert(key)
+			}
+		}
+		if iter%2 == 0 { // either side may be indexed
+			Index(local, goavl.IntCodec)
+		} else {
+			Index(remote, goavl.IntCodec)
+		}
+
+		diff := reconcile(t, local, remote)
+		if got, expected := sorted(diff.Local), sorted(onlyLocal); got != expected {
+			t.Fatalf("Local = %v; expected %v\n", got, expected)
+		}
+		if got, expected := sorted(diff.Remote), sorted(onlyRemote); got != expected {
+			t.Fatalf("Remote = %v; expected %v\n", got, expected)
+		}
+	}
+}
+
+func TestReconcileRounds(t *testing.T) {
+	local, remote := goavl.NewTree(), goavl.NewTree()
+	for i := 0; i < 1<<14; i++ {
+		local.Insert(goavl.Int(i))
+		remote.Insert(goavl.Int(i))
+	}
+	Index(local, goavl.IntCodec)
+	Index(remote, goavl.IntCodec)
+	if diff := reconcile(t, local, remote); diff.Rounds != 1 || len(diff.Local)+len(diff.Remote) != 0 {
+		t.Errorf("identical trees: %+v\n", diff)
+	}
+
+	remote.Delete(goavl.Int(12345))
+	diff := reconcile(t, local, remote)
+	if len(diff.Local) != 1 || diff.Local[0] != goavl.Int(12345) || len(diff.Remote) != 0 {
+		t.Errorf("Local = %v, Remote = %v; expected [12345], []\n", diff.Local, diff.Remote)
+	}
+	if diff.Rounds > 40 {
+		t.Errorf("a single difference took %d rounds\n", diff.Rounds)
+	}
+	t.Logf("a single difference among %d keys took %d rounds\n", local.Size(), diff.Rounds)
+
+	// One of the trees is empty.
+	diff = reconcile(t, goavl.NewTree(), remote)
+	if len(diff.Remote) != remote.Size() || len(diff.Local) != 0 {
+		t.Errorf("empty local tree: %d remote keys found; expected %d\n", len(diff.Remote), remote.Size())
+	}
+}
@@ -0,0 +1,230 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package merkle
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+
+	"github.com/ckatsak/goavl"
+)
+
+// leafSize is the number of keys in a range, at or below which the keys are
+// exchanged instead of bisecting the range further.
+const leafSize = 16
+
+// request is sent by Reconcile, asking the responder to compare the Hash of
+// one of its ranges against that of the initiator.
+type request struct {
+	Lo, Hi       []byte // encoded bounds of the range
+	LoSet, HiSet bool   // whether the range is bounded on each side
+	Hash         Hash
+	Count        int
+	Done         bool // ends the session
+}
+
+// response is sent by Respond. If the Hashes of the range match, Match is
+// set. Otherwise, either Keys holds all encoded keys of the responder in the
+// range, or Mid is an encoded key at which the initiator should bisect it.
+type response struct {
+	Match bool
+	Keys  [][]byte
+	Mid   []byte
+}
+
+// Differences are the differences between the keys of two trees, as found by
+// Reconcile.
+type Differences struct {
+	Local  []goavl.Item // keys that only the local tree holds
+	Remote []goavl.Item // keys that only the remote tree holds
+	Rounds int          // number of request/response round trips
+}
+
+// Respond serves a Reconcile session over rw, comparing ranges of the keys
+// of t, encoded with codec, against those of the initiator, until the
+// initiator ends the session. t must not be modified during the session.
+func Respond(rw io.ReadWriter, t *goavl.Tree, codec goavl.KeyCodec) error {
+	enc, dec := gob.NewEncoder(rw), gob.NewDecoder(rw)
+	for {
+		var req request
+		if err := dec.Decode(&req); err != nil {
+			return err
+		}
+		if req.Done {
+			return nil
+		}
+		resp, err := respond(t, codec, &req)
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// respond returns the response of t to req.
+func respond(t *goavl.Tree, codec goavl.KeyCodec, req *request) (*response, error) {
+	var lo, hi goavl.Item
+	var err error
+	if req.LoSet {
+		if lo, err = codec.Decode(req.Lo); err != nil {
+			return nil, err
+		}
+	}
+	if req.HiSet {
+		if hi, err = codec.Decode(req.Hi); err != nil {
+			return nil, err
+		}
+	}
+	s, err := summarize(t, codec, lo, hi)
+	if err != nil {
+		return nil, err
+	}
+	if s.count == req.Count && s.hash() == req.Hash {
+		return &response{Match: true}, nil
+	}
+
+	// Send the keys of small ranges; bisect large ones at the median.
+	resp := &response{}
+	if s.count <= leafSize || req.Count <= leafSize {
+		t.AscendRange(lo, hi, func(key goavl.Item) bool {
+			var raw []byte
+			if raw, err = codec.Encode(key); err != nil {
+				return false
+			}
+			resp.Keys = append(resp.Keys, raw)
+			return true
+		})
+		return resp, err
+	}
+	first := 0
+	if lo != nil {
+		first = t.Rank(lo)
+	}
+	mid, err := t.Select(first + s.count/2)
+	if err != nil {
+		return nil, err
+	}
+	resp.Mid, err = codec.Encode(mid)
+	return resp, err
+}
+
+// Reconcile finds the differences between the keys of t, encoded with codec,
+// and those of a remote tree, whose host runs Respond on the other end of rw.
+//
+// Starting from the whole range of keys, it sends the Hash of each range to
+// the responder. Ranges whose Hashes match are identical; mismatching ones
+// are bisected at the median key of the responder, recursively, until they
+// hold few keys, which are then exchanged. Thus, for d differences among n
+// keys, O(d log n) Hashes are exchanged, each computed in O(log n) time if
+// both trees are indexed with codec (see Index). t must not be modified during
+// the session.
+func Reconcile(rw io.ReadWriter, t *goavl.Tree, codec goavl.KeyCodec) (*Differences, error) {
+	r := &reconciler{t: t, codec: codec, enc: gob.NewEncoder(rw), dec: gob.NewDecoder(rw), diff: &Differences{}}
+	if err := r.reconcile(nil, nil); err != nil {
+		return nil, err
+	}
+	if err := r.enc.Encode(&request{Done: true}); err != nil {
+		return nil, err
+	}
+	return r.diff, nil
+}
+
+// reconciler is the state of the initiator of a Reconcile session.
+type reconciler struct {
+	t     *goavl.Tree
+	codec goavl.KeyCodec
+	enc   *gob.Encoder
+	dec   *gob.Decoder
+	diff  *Differences
+}
+
+// reconcile finds the differences in [lo, hi).
+func (r *reconciler) reconcile(lo, hi goavl.Item) error {
+	s, err := summarize(r.t, r.codec, lo, hi)
+	if err != nil {
+		return err
+	}
+	req := &request{Hash: s.hash(), Count: s.count}
+	if lo != nil {
+		if req.Lo, err = r.codec.Encode(lo); err != nil {
+			return err
+		}
+		req.LoSet = true
+	}
+	if hi != nil {
+		if req.Hi, err = r.codec.Encode(hi); err != nil {
+			return err
+		}
+		req.HiSet = true
+	}
+	if err := r.enc.Encode(req); err != nil {
+		return err
+	}
+	var resp response
+	if err := r.dec.Decode(&resp); err != nil {
+		return err
+	}
+	r.diff.Rounds++
+
+	switch {
+	case resp.Match:
+		return nil
+	case resp.Mid != nil:
+		mid, err := r.codec.Decode(resp.Mid)
+		if err != nil {
+			return err
+		}
+		if (lo != nil && !lo.Less(mid)) || (hi != nil && !mid.Less(hi)) {
+			return fmt.Errorf("Invalid bisection of [%v, %v) at %v", lo, hi, mid)
+		}
+		if err := r.reconcile(lo, mid); err != nil {
+			return err
+		}
+		return r.reconcile(mid, hi)
+	}
+	return r.compare(lo, hi, resp.Keys)
+}
+
+// compare compares the local keys in [lo, hi) to the encoded remote ones.
+func (r *reconciler) compare(lo, hi goavl.Item, remote [][]byte) error {
+	remoteSet := make(map[string]bool, len(remote))
+	for _, raw := range remote {
+		remoteSet[string(raw)] = true
+	}
+	var err error
+	r.t.AscendRange(lo, hi, func(key goavl.Item) bool {
+		var raw []byte
+		if raw, err = r.codec.Encode(key); err != nil {
+			return false
+		}
+		if remoteSet[string(raw)] {
+			delete(remoteSet, string(raw))
+		} else {
+			r.diff.Local = append(r.diff.Local, key)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	for _, raw := range remote {
+		if !remoteSet[string(raw)] {
+			continue
+		}
+		key, err := r.codec.Decode(raw)
+		if err != nil {
+			return err
+		}
+		r.diff.Remote = append(r.diff.Remote, key)
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Reverse is an Item that wraps another Item and reverses its order, e.g. a
+// tree of Reverse{Int(i)} keeps the Ints in descending order.
+type Reverse struct {
+	Item
+}
+
+// Equal implements Item.
+func (r Reverse) Equal(to Item) bool {
+	return r.Item.Equal(to.(Reverse).Item)
+}
+
+// Less implements Item.
+func (r Reverse) Less(than Item) bool {
+	return than.(Reverse).Item.Less(r.Item)
+}
+
+// Comparator compares two values, returning a negative number, zero or a
+// positive number if a is less than, equal to or greater than b.
+type Comparator func(a, b interface{}) int
+
+// ReverseComparator returns a Comparator that reverses the order of cmp.
+func ReverseComparator(cmp Comparator) Comparator {
+	return func(a, b interface{}) int {
+		return cmp(b, a)
+	}
+}
+
+// ByFunc is an Item that orders an arbitrary Value by a Comparator. All
+// ByFuncs in a tree must use the same Compare function.
+type ByFunc struct {
+	Value   interface{}
+	Compare Comparator
+}
+
+// Equal implements Item.
+func (b ByFunc) Equal(to Item) bool {
+	return b.Compare(b.Value, to.(ByFunc).Value) == 0
+}
+
+// Less implements Item.
+func (b ByFunc) Less(than Item) bool {
+	return b.Compare(b.Value, than.(ByFunc).Value) < 0
+}
+
+// CaseInsensitive is an Item of string, ordered and compared under Unicode
+// simple case folding, as by strings.EqualFold; e.g. "Go" and "GO" are equal.
+type CaseInsensitive string
+
+// Equal implements Item.
+func (s CaseInsensitive) Equal(to Item) bool {
+	return compareFold(string(s), string(to.(CaseInsensitive))) == 0
+}
+
+// Less implements Item.
+func (s CaseInsensitive) Less(than Item) bool {
+	return compareFold(string(s), string(than.(CaseInsensitive))) < 0
+}
+
+// compareFold compares a and b rune by rune, under simple case folding.
+func compareFold(a, b string) int {
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if ra, rb = foldRune(ra), foldRune(rb); ra != rb {
+			if ra < rb {
+				return -1
+			}
+			return 1
+		}
+		a, b = a[na:], b[nb:]
+	}
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	}
+	return 1
+}
+
+// foldRune returns the smallest rune in the simple case folding orbit of r,
+// which is the same for all runes that are equal under case folding.
+func foldRune(r rune) rune {
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
+
+// Collated is an Item of string, ordered by an arbitrary string comparison
+// function, such as the CompareString method of a Collator of the
+// golang.org/x/text/collate package, which implements the Unicode Collation
+// Algorithm. All Collateds in a tree must use the same Compare function.
+type Collated struct {
+	Value   string
+	Compare func(a, b string) int
+}
+
+// Equal implements Item.
+func (c Collated) Equal(to Item) bool {
+	return c.Compare(c.Value, to.(Collated).Value) == 0
+}
+
+// Less implements Item.
+func (c Collated) Less(than Item) bool {
+	return c.Compare(c.Value, than.(Collated).Value) < 0
+}
+
+// String returns the string value of c.
+func (c Collated) String() string {
+	return c.Value
+}
+
+// Natural is an Item of string, ordered in "natural" order: runs of decimal
+// digits are compared by their numeric values, so that e.g. "file2" is less
+// than "file10". Strings that are equal in natural order but not byte-wise
+// (e.g. "file02" and "file2") are ordered byte-wise, so that Natural is equal
+// only to the very same string.
+type Natural string
+
+// Equal implements Item.
+func (s Natural) Equal(to Item) bool {
+	return s == to.(Natural)
+}
+
+// Less implements Item.
+func (s Natural) Less(than Item) bool {
+	t := than.(Natural)
+	if c := compareNatural(string(s), string(t)); c != 0 {
+		return c < 0
+	}
+	return s < t
+}
+
+// compareNatural compares a and b in natural order.
+func compareNatural(a, b string) int {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			var da, db string
+			da, a = splitDigits(a)
+			db, b = splitDigits(b)
+			// Compare the numbers, ignoring their leading zeros.
+			da, db = trimZeros(da), trimZeros(db)
+			if len(da) != len(db) {
+				if len(da) < len(db) {
+					return -1
+				}
+				return 1
+			}
+			if da != db {
+				if da < db {
+					return -1
+				}
+				return 1
+			}
+			continue
+		}
+		if a[0] != b[0] {
+			if a[0] < b[0] {
+				return -1
+			}
+			return 1
+		}
+		a, b = a[1:], b[1:]
+	}
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	}
+	return 1
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// splitDigits splits s into its leading run of digits and the rest of it.
+func splitDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// trimZeros strips the leading zeros of the run of digits s.
+func trimZeros(s string) string {
+	for len(s) > 1 && s[0] == '0' {
+		s = s[1:]
+	}
+	return s
+}
+
+// NilsFirst is an Item that wraps another, possibly nil, Item and places nil
+// and zero values (as reported by reflect.Value.IsZero) before all others.
+// nil is less than any non-nil zero value; the rest are ordered by the
+// wrapped Item.
+type NilsFirst struct {
+	Item
+}
+
+// Equal implements Item.
+func (n NilsFirst) Equal(to Item) bool {
+	return compareNils(n.Item, to.(NilsFirst).Item, -1) == 0
+}
+
+// Less implements Item.
+func (n NilsFirst) Less(than Item) bool {
+	return compareNils(n.Item, than.(NilsFirst).Item, -1) < 0
+}
+
+// NilsLast is an Item that wraps another, possibly nil, Item and places nil
+// and zero values (as reported by reflect.Value.IsZero) after all others.
+// nil is greater than any non-nil zero value; the rest are ordered by the
+// wrapped Item.
+type NilsLast struct {
+	Item
+}
+
+// Equal implements Item.
+func (n NilsLast) Equal(to Item) bool {
+	return compareNils(n.Item, to.(NilsLast).Item, 1) == 0
+}
+
+// Less implements Item.
+func (n NilsLast) Less(than Item) bool {
+	return compareNils(n.Item, than.(NilsLast).Item, 1) < 0
+}
+
+// compareNils compares a and b, placing nil and zero values first if zero is
+// -1, or last if it is +1.
+func compareNils(a, b Item, zero int) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return zero
+	case b == nil:
+		return -zero
+	}
+	za, zb := reflect.ValueOf(a).IsZero(), reflect.ValueOf(b).IsZero()
+	if za != zb {
+		if za {
+			return zero
+		}
+		return -zero
+	}
+	if a.Less(b) {
+		return -1
+	}
+	if a.Equal(b) {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,129 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ckatsak/goavl"
+	"github.com/ckatsak/goavl/avltest"
+)
+
+// inOrder returns the in-order traversal of tree, formatted as a string.
+func inOrder(tree *goavl.Tree) string {
+	return fmt.Sprint(tree.InOrder())
+}
+
+func insertAll(t *testing.T, tree *goavl.Tree, keys ...goavl.Item) {
+	t.Helper()
+	for _, key := range keys {
+		if err := tree.Insert(key); err != nil {
+			t.Errorf("\t%v\n", err)
+		}
+	}
+}
+
+func TestReverse(t *testing.T) {
+	tree := goavl.NewTree()
+	insertAll(t, tree, goavl.Reverse{goavl.Int(2)}, goavl.Reverse{goavl.Int(3)}, goavl.Reverse{goavl.Int(1)})
+	if got := inOrder(tree); got != "[{3} {2} {1}]" {
+		t.Errorf("tree.InOrder() = %s; expected descending order\n", got)
+	}
+	min, _ := tree.Min()
+	if min.(goavl.Reverse).Item != goavl.Int(3) {
+		t.Errorf("tree.Min() = %v; expected {3}\n", min)
+	}
+}
+
+func TestByFunc(t *testing.T) {
+	byLen := func(a, b interface{}) int { return len(a.(string)) - len(b.(string)) }
+	tree := goavl.NewTree()
+	for _, cmp := range []goavl.Comparator{byLen, goavl.ReverseComparator(byLen)} {
+		tree = goavl.NewTree()
+		insertAll(t, tree, goavl.ByFunc{"ccc", cmp}, goavl.ByFunc{"a", cmp}, goavl.ByFunc{"bb", cmp})
+		if err := tree.Insert(goavl.ByFunc{"zz", cmp}); err == nil {
+			t.Errorf("\tExpected an error for a string of an existing length!\n")
+		}
+	}
+	max, _ := tree.Max()
+	if max.(goavl.ByFunc).Value != "a" {
+		t.Errorf("tree.Max() = %v; expected a under the reversed comparator\n", max)
+	}
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	avltest.CheckOrdering(t, []goavl.Item{
+		goavl.CaseInsensitive(""), goavl.CaseInsensitive("a"), goavl.CaseInsensitive("A"),
+		goavl.CaseInsensitive("ab"), goavl.CaseInsensitive("B"), goavl.CaseInsensitive("straße"),
+		goavl.CaseInsensitive("STRAẞE"), goavl.CaseInsensitive("Σίσυφος"), goavl.CaseInsensitive("ΣΊΣΥΦΟΣ"),
+	})
+
+	tree := goavl.NewTree()
+	insertAll(t, tree, goavl.CaseInsensitive("banana"), goavl.CaseInsensitive("Apple"), goavl.CaseInsensitive("cherry"))
+	if err := tree.Insert(goavl.CaseInsensitive("APPLE")); err == nil {
+		t.Errorf("\tExpected an error for APPLE!\n")
+	}
+	if got := inOrder(tree); got != "[Apple banana cherry]" {
+		t.Errorf("tree.InOrder() = %s; expected [Apple banana cherry]\n", got)
+	}
+}
+
+func TestCollated(t *testing.T) {
+	// A toy collation that ignores hyphens.
+	cmp := func(a, b string) int {
+		return strings.Compare(strings.ReplaceAll(a, "-", ""), strings.ReplaceAll(b, "-", ""))
+	}
+	tree := goavl.NewTree()
+	insertAll(t, tree, goavl.Collated{"co-op", cmp}, goavl.Collated{"coin", cmp}, goavl.Collated{"cook", cmp})
+	if got := inOrder(tree); got != "[coin cook co-op]" {
+		t.Errorf("tree.InOrder() = %s; expected [coin cook co-op]\n", got)
+	}
+}
+
+func TestNatural(t *testing.T) {
+	items := []goavl.Item{}
+	for _, s := range []string{"", "file", "file1", "file01", "file2", "file10", "file10a", "file10b", "filf", "x0", "x00", "007"} {
+		items = append(items, goavl.Natural(s))
+	}
+	avltest.CheckOrdering(t, items)
+
+	tree := goavl.NewTree()
+	insertAll(t, tree, goavl.Natural("file10"), goavl.Natural("file2"), goavl.Natural("file1"), goavl.Natural("file02"))
+	if got := inOrder(tree); got != "[file1 file02 file2 file10]" {
+		t.Errorf("tree.InOrder() = %s; expected [file1 file02 file2 file10]\n", got)
+	}
+}
+
+func TestNils(t *testing.T) {
+	first := []goavl.Item{goavl.NilsFirst{nil}, goavl.NilsFirst{goavl.Int(0)}, goavl.NilsFirst{goavl.Int(-1)}, goavl.NilsFirst{goavl.Int(1)}}
+	last := []goavl.Item{goavl.NilsLast{nil}, goavl.NilsLast{goavl.Int(0)}, goavl.NilsLast{goavl.Int(-1)}, goavl.NilsLast{goavl.Int(1)}}
+	avltest.CheckOrdering(t, first)
+	avltest.CheckOrdering(t, last)
+
+	tree := goavl.NewTree()
+	insertAll(t, tree, first...)
+	if got := inOrder(tree); got != "[{<nil>} {0} {-1} {1}]" {
+		t.Errorf("tree.InOrder() = %s; expected [{<nil>} {0} {-1} {1}]\n", got)
+	}
+	tree = goavl.NewTree()
+	insertAll(t, tree, last...)
+	if got := inOrder(tree); got != "[{-1} {1} {0} {<nil>}]" {
+		t.Errorf("tree.InOrder() = %s; expected [{-1} {1} {0} {<nil>}]\n", got)
+	}
+
+	// Adapters compose.
+	tree = goavl.NewTree()
+	insertAll(t, tree, goavl.Reverse{goavl.NilsFirst{goavl.String("a")}}, goavl.Reverse{goavl.NilsFirst{nil}},
+		goavl.Reverse{goavl.NilsFirst{goavl.String("b")}})
+	if got := inOrder(tree); got != "[{{b}} {{a}} {{<nil>}}]" {
+		t.Errorf("tree.InOrder() = %s; expected [{{b}} {{a}} {{<nil>}}]\n", got)
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package paged
+
+import (
+	"fmt"
+
+	"github.com/ckatsak/goavl"
+)
+
+// The AVL algorithms below mirror those of goavl.Tree, except that nodes
+// refer to their children by page number, and must be passed to modify before
+// being modified. They return the page number of the new root of the subtree
+// they were called on.
+
+// update updates the height of n, which must have been passed to modify.
+func (t *Tree) update(n *node) {
+	n.h = 1 + max(t.height(n.left), t.height(n.right))
+}
+
+// balanceFactor returns the balance factor of n.
+func (t *Tree) balanceFactor(n *node) int {
+	return t.height(n.left) - t.height(n.right)
+}
+
+func (t *Tree) rotateRight(n *node) uint64 {
+	l := t.get(n.left)
+	t.modify(l)
+	t.modify(n)
+	n.left, l.right = l.right, n.id
+	t.update(n)
+	t.update(l)
+	return l.id
+}
+
+func (t *Tree) rotateLeft(n *node) uint64 {
+	r := t.get(n.right)
+	t.modify(r)
+	t.modify(n)
+	n.right, r.left = r.left, n.id
+	t.update(n)
+	t.update(r)
+	return r.id
+}
+
+// rebalance updates the height of n, which must have been passed to modify
+// and whose subtrees are both balanced but may differ in height by 2, and
+// rebalances it.
+func (t *Tree) rebalance(n *node) uint64 {
+	t.update(n)
+	switch bal := t.balanceFactor(n); {
+	case bal > 1:
+		if l := t.get(n.left); t.balanceFactor(l) < 0 {
+			n.left = t.rotateLeft(l)
+		}
+		return t.rotateRight(n)
+	case bal < -1:
+		if r := t.get(n.right); t.balanceFactor(r) > 0 {
+			n.right = t.rotateRight(r)
+		}
+		return t.rotateLeft(n)
+	}
+	return n.id
+}
+
+// insert inserts key, whose encoding is raw, into the subtree rooted with the
+// node stored in page id.
+func (t *Tree) insert(id uint64, key goavl.Item, raw []byte) (uint64, error) {
+	if id == 0 {
+		return t.newNode(key, raw).id, nil
+	}
+	n := t.get(id)
+	switch {
+	case key.Less(n.key):
+		left, err := t.insert(n.left, key, raw)
+		if err != nil {
+			return id, err
+		}
+		t.modify(n)
+		n.left = left
+	case key.Equal(n.key):
+		return id, fmt.Errorf("Key already in the tree: %v", key)
+	default:
+		right, err := t.insert(n.right, key, raw)
+		if err != nil {
+			return id, err
+		}
+		t.modify(n)
+		n.right = right
+	}
+	return t.rebalance(n), nil
+}
+
+// delete deletes key from the subtree rooted with the node stored in page id.
+func (t *Tree) delete(id uint64, key goavl.Item) (uint64, error) {
+	if id == 0 {
+		return 0, fmt.Errorf("Key not found in the tree: %v", key)
+	}
+	n := t.get(id)
+	switch {
+	case key.Less(n.key):
+		left, err := t.delete(n.left, key)
+		if err != nil {
+			return id, err
+		}
+		t.modify(n)
+		n.left = left
+	case key.Equal(n.key):
+		if n.left == 0 || n.right == 0 {
+			child := n.left + n.right // at most one of them is non-zero
+			t.release(n)
+			return child, nil
+		}
+		right, min := t.deleteMin(n.right)
+		t.modify(n)
+		n.key, n.raw, n.right = min.key, min.raw, right
+		t.release(min)
+	default:
+		right, err := t.delete(n.right, key)
+		if err != nil {
+			return id, err
+		}
+		t.modify(n)
+		n.right = right
+	}
+	return t.rebalance(n), nil
+}
+
+// deleteMin removes the node of the minimum key from the non-empty subtree
+// rooted with the node stored in page id, and returns it, without releasing
+// its page.
+func (t *Tree) deleteMin(id uint64) (uint64, *node) {
+	n := t.get(id)
+	if n.left == 0 {
+		return n.right, n
+	}
+	left, min := t.deleteMin(n.left)
+	t.modify(n)
+	n.left = left
+	return t.rebalance(n), min
+}
+
+// search returns the node of key in the subtree rooted with the node stored
+// in page id, or nil if there is none.
+func (t *Tree) search(id uint64, key goavl.Item) *node {
+	for id != 0 {
+		n := t.get(id)
+		switch {
+		case key.Less(n.key):
+			id = n.left
+		case key.Equal(n.key):
+			return n
+		default:
+			id = n.right
+		}
+	}
+	return nil
+}
+
+// scan returns the node stored in page id, or nil if id is 0, for read-only
+// traversals, trimming the buffer pool right away so that traversing a large
+// tree never lets it grow beyond its capacity. The traversal keeps referring
+// to the nodes on its current path even if they are evicted, which is safe
+// since it does not modify them.
+func (t *Tree) scan(id uint64) *node {
+	n := t.get(id)
+	t.trim()
+	return n
+}
+
+// ascendRange calls visit for each key in [lo, hi) in the subtree rooted with
+// the node stored in page id, in ascending order, as goavl.Tree.AscendRange.
+// It returns false if the traversal was stopped.
+func (t *Tree) ascendRange(id uint64, lo, hi goavl.Item, visit func(goavl.Item) bool) bool {
+	if id == 0 {
+		return true
+	}
+	n := t.scan(id)
+	if lo == nil || !n.key.Less(lo) {
+		if !t.ascendRange(n.left, lo, hi, visit) {
+			return false
+		}
+		if hi != nil && !n.key.Less(hi) {
+			return false
+		}
+		if !visit(n.key) {
+			return false
+		}
+	}
+	return t.ascendRange(n.right, lo, hi, visit)
+}
+
+// preOrder appends the keys of the subtree rooted with the node stored in page
+// id to keys, in pre-order, and returns the resulting slice.
+func (t *Tree) preOrder(id uint64, keys []goavl.Item) []goavl.Item {
+	if id == 0 {
+		return keys
+	}
+	n := t.scan(id)
+	keys = append(keys, n.key)
+	keys = t.preOrder(n.left, keys)
+	return t.preOrder(n.right, keys)
+}
@@ -0,0 +1,331 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+// Package paged implements a disk-backed AVL tree, for sets of keys that do
+// not fit in memory.
+//
+// Each node of the tree is stored in a fixed-size page of a single file,
+// along with its key, as encoded by a goavl.KeyCodec. Recently used nodes
+// are kept in memory by a buffer pool of limited capacity, which evicts the
+// least recently used ones, writing them to their pages if they were
+// modified.
+//
+// Sync makes the current state of the tree durable. Pages that hold the last
+// synced state are never overwritten; nodes that are modified after a Sync
+// are copied to new pages instead, along with their ancestors. Sync writes
+// the new pages first and then atomically switches to them by writing a
+// header, which is stored alternately in one of two checksummed slots. Hence,
+// if the process or the system crashes, the tree is found in the state it
+// was in at the last successful Sync when the file is opened again.
+package paged
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+
+	"github.com/ckatsak/goavl"
+)
+
+// Default values of the Options.
+const (
+	DefaultPageSize  = 4096
+	DefaultCacheSize = 1024
+)
+
+// MinPageSize is the minimum size of the pages, which must also hold the two
+// header slots.
+const MinPageSize = 2 * slotSize
+
+// Options configure the opening of a Tree.
+type Options struct {
+	// PageSize is the size of the pages of a new file, in bytes; it is
+	// ignored for existing files. It limits the size of the encoded keys to
+	// PageSize-24 bytes. If 0, DefaultPageSize is used.
+	PageSize int
+	// CacheSize is the capacity of the buffer pool, in pages. If 0,
+	// DefaultCacheSize is used.
+	CacheSize int
+}
+
+// Tree is an AVL tree whose nodes are stored in the pages of a file. It is
+// not safe for concurrent use.
+//
+// If an I/O error occurs, the Tree stops working, and all of its methods
+// return the same error; the file then has to be opened again, to recover its
+// last synced state.
+type Tree struct {
+	f        *os.File
+	codec    goavl.KeyCodec
+	pageSize int
+	capacity int
+
+	seq      uint64 // of the last synced header
+	root     uint64
+	size     int
+	numPages uint64
+
+	free    []uint64        // pages that may be allocated
+	pending []uint64        // pages of the synced state freed since the last Sync
+	fresh   map[uint64]bool // pages allocated since the last Sync
+
+	cache map[uint64]*list.Element // of *node, by page number
+	lru   *list.List
+
+	err error
+}
+
+// Open opens the Tree stored in the file at path, creating it if it does not
+// exist, whose keys are encoded with codec. If opts is nil, the default
+// Options are used.
+//
+// Opening an existing file takes time linear in the size of the tree, since
+// all nodes are visited in order to find the free pages.
+func Open(path string, codec goavl.KeyCodec, opts *Options) (*Tree, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	t := &Tree{
+		codec:    codec,
+		pageSize: opts.PageSize,
+		capacity: opts.CacheSize,
+		numPages: 1,
+		fresh:    make(map[uint64]bool),
+		cache:    make(map[uint64]*list.Element),
+		lru:      list.New(),
+	}
+	if t.pageSize == 0 {
+		t.pageSize = DefaultPageSize
+	}
+	if t.capacity <= 0 {
+		t.capacity = DefaultCacheSize
+	}
+	if t.pageSize < MinPageSize {
+		return nil, fmt.Errorf("Page size too small: %d (at least %d)", t.pageSize, MinPageSize)
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	t.f = f
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.Size() == 0 {
+		err = t.Sync()
+	} else {
+		err = t.load()
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("Opening %s: %v", path, err)
+	}
+	return t, nil
+}
+
+// load loads the state of the Tree from the last valid header of its file,
+// and discards any pages written after it.
+func (t *Tree) load() error {
+	h, err := readHeader(t.f)
+	if err != nil {
+		return err
+	}
+	t.seq, t.pageSize, t.root, t.size, t.numPages = h.seq, h.pageSize, h.root, h.size, h.numPages
+	if t.pageSize < MinPageSize {
+		return fmt.Errorf("Invalid page size: %d", t.pageSize)
+	}
+	return t.do(func() error {
+		t.reclaim()
+		return t.f.Truncate(int64(t.numPages) * int64(t.pageSize))
+	})
+}
+
+// do calls fn, which may panic with an ioError, and then trims the buffer
+// pool. It returns the error returned by fn, or the I/O error, which it also
+// records, so that all further calls fail.
+func (t *Tree) do(fn func() error) (err error) {
+	if t.err != nil {
+		return t.err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(ioError)
+			if !ok {
+				panic(r)
+			}
+			t.err, err = e.err, e.err
+		}
+	}()
+	err = fn()
+	t.trim()
+	return err
+}
+
+// Sync writes all modified nodes to the file, and makes the current state of
+// the Tree durable.
+func (t *Tree) Sync() error {
+	return t.do(func() error {
+		t.sync()
+		return nil
+	})
+}
+
+// Close syncs the Tree and closes its file.
+func (t *Tree) Close() error {
+	err := t.Sync()
+	if cerr := t.f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		t.err = fmt.Errorf("Tree is closed")
+	}
+	return err
+}
+
+// Size returns the current number of keys in the Tree.
+func (t *Tree) Size() int {
+	return t.size
+}
+
+// Height returns the current height of the Tree.
+func (t *Tree) Height() (h int) {
+	t.do(func() error {
+		h = t.height(t.root)
+		return nil
+	})
+	return h
+}
+
+// Insert inserts a key into the Tree and returns an error value, which is
+// non-nil if the key already exists in the tree, if it cannot be encoded, or
+// if its encoding does not fit in a page.
+func (t *Tree) Insert(key goavl.Item) error {
+	raw, err := t.codec.Encode(key)
+	if err != nil {
+		return err
+	}
+	if len(raw) > t.pageSize-offKey {
+		return fmt.Errorf("Key too large: %d bytes (at most %d)", len(raw), t.pageSize-offKey)
+	}
+	return t.do(func() error {
+		root, err := t.insert(t.root, key, raw)
+		if err == nil {
+			t.root = root
+			t.size++
+		}
+		return err
+	})
+}
+
+// Delete removes a key from the Tree and returns an error value, which is
+// non-nil if the key doesn't exist in the tree.
+func (t *Tree) Delete(key goavl.Item) error {
+	return t.do(func() error {
+		root, err := t.delete(t.root, key)
+		if err == nil {
+			t.root = root
+			t.size--
+		}
+		return err
+	})
+}
+
+// Search looks up key in the Tree and returns the Item stored in it that is
+// equal to key, along with an error value, which is non-nil if the key
+// doesn't exist in the tree.
+func (t *Tree) Search(key goavl.Item) (found goavl.Item, err error) {
+	err = t.do(func() error {
+		n := t.search(t.root, key)
+		if n == nil {
+			return fmt.Errorf("Key not found in the tree: %v", key)
+		}
+		found = n.key
+		return nil
+	})
+	return found, err
+}
+
+// Contains reports whether key exists in the Tree.
+func (t *Tree) Contains(key goavl.Item) bool {
+	_, err := t.Search(key)
+	return err == nil
+}
+
+// Min returns the minimum key in the Tree and an error value, which is
+// non-nil if the tree is empty.
+func (t *Tree) Min() (min goavl.Item, err error) {
+	err = t.do(func() error {
+		if t.root == 0 {
+			return fmt.Errorf("Empty tree")
+		}
+		n := t.get(t.root)
+		for n.left != 0 {
+			n = t.get(n.left)
+		}
+		min = n.key
+		return nil
+	})
+	return min, err
+}
+
+// Max returns the maximum key in the Tree and an error value, which is
+// non-nil if the tree is empty.
+func (t *Tree) Max() (max goavl.Item, err error) {
+	err = t.do(func() error {
+		if t.root == 0 {
+			return fmt.Errorf("Empty tree")
+		}
+		n := t.get(t.root)
+		for n.right != 0 {
+			n = t.get(n.right)
+		}
+		max = n.key
+		return nil
+	})
+	return max, err
+}
+
+// AscendRange calls visit for each key in the Tree that is greater than or
+// equal to lo and less than hi, in ascending order, until visit returns
+// false. A nil lo or hi leaves the range unbounded on that side. It returns
+// an error value, which is non-nil if an I/O error occurred. visit must not
+// modify the tree.
+func (t *Tree) AscendRange(lo, hi goavl.Item, visit func(goavl.Item) bool) error {
+	return t.do(func() error {
+		t.ascendRange(t.root, lo, hi, visit)
+		return nil
+	})
+}
+
+// InOrder returns a slice of all keys in the Tree, in ascending order. It
+// returns nil if an I/O error occurred.
+func (t *Tree) InOrder() []goavl.Item {
+	keys := make([]goavl.Item, 0, t.size)
+	if t.AscendRange(nil, nil, func(key goavl.Item) bool {
+		keys = append(keys, key)
+		return true
+	}) != nil {
+		return nil
+	}
+	return keys
+}
+
+// PreOrder returns a slice of all keys in the Tree, in pre-order. It returns
+// nil if an I/O error occurred.
+func (t *Tree) PreOrder() (keys []goavl.Item) {
+	if t.do(func() error {
+		keys = t.preOrder(t.root, make([]goavl.Item, 0, t.size))
+		return nil
+	}) != nil {
+		return nil
+	}
+	return keys
+}
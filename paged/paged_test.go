@@ -0,0 +1,249 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package paged
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ckatsak/goavl"
+	"github.com/ckatsak/goavl/avltest"
+)
+
+// small are Options that make the buffer pool evict nodes all the time.
+var small = &Options{PageSize: MinPageSize, CacheSize: 4}
+
+func open(t *testing.T, path string, opts *Options) *Tree {
+	t.Helper()
+	tree, err := Open(path, goavl.IntCodec, opts)
+	if err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	return tree
+}
+
+// verify checks that the pages of tree form a valid AVL tree holding exactly
+// keys, in ascending order.
+func verify(t *testing.T, tree *Tree, keys []int) {
+	t.Helper()
+	var walk func(id uint64, lo, hi goavl.Item) (int, int)
+	walk = func(id uint64, lo, hi goavl.Item) (int, int) {
+		if id == 0 {
+			return 0, 0
+		}
+		n := tree.get(id)
+		if (lo != nil && !lo.Less(n.key)) || (hi != nil && !n.key.Less(hi)) {
+			t.Fatalf("key %v out of order\n", n.key)
+		}
+		ls, lh := walk(n.left, lo, n.key)
+		rs, rh := walk(n.right, n.key, hi)
+		if n.h != 1+max(lh, rh) || lh-rh < -1 || lh-rh > 1 {
+			t.Fatalf("node %v: height %d, children heights %d and %d\n", n.key, n.h, lh, rh)
+		}
+		return 1 + ls + rs, n.h
+	}
+	if size, _ := walk(tree.root, nil, nil); size != tree.Size() || size != len(keys) {
+		t.Fatalf("tree has %d nodes, Size() = %d; expected %d\n", size, tree.Size(), len(keys))
+	}
+	items := make([]goavl.Item, len(keys))
+	for i, key := range keys {
+		items[i] = goavl.Int(key)
+	}
+	avltest.VerifyTraversal(t, tree, items)
+}
+
+func TestTree(t *testing.T) {
+	tree := open(t, filepath.Join(t.TempDir(), "tree"), small)
+	defer tree.Close()
+	avltest.Run(t, tree, rand.New(rand.NewSource(1)), 2000, func(r *rand.Rand) goavl.Item {
+		return goavl.Int(r.Intn(500))
+	})
+	if len(tree.cache) > small.CacheSize || tree.lru.Len() > small.CacheSize {
+		t.Errorf("buffer pool holds %d nodes; expected at most %d\n", tree.lru.Len(), small.CacheSize)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree")
+	tree := open(t, path, small)
+	keys := rand.Perm(300)
+	for _, key := range keys {
+		if err := tree.Insert(goavl.Int(key)); err != nil {
+			t.Fatalf("\t%v\n", err)
+		}
+	}
+	if err := tree.Close(); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	if err := tree.Insert(goavl.Int(-1)); err == nil {
+		t.Errorf("\tExpected an error for inserting into a closed tree!\n")
+	}
+
+	tree = open(t, path, nil)
+	verify(t, tree, keys)
+	for _, key := range keys[:100] {
+		if err := tree.Delete(goavl.Int(key)); err != nil {
+			t.Fatalf("\t%v\n", err)
+		}
+	}
+	if err := tree.Close(); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+
+	tree = open(t, path, small)
+	defer tree.Close()
+	verify(t, tree, keys[100:])
+	verifyPages(t, tree)
+
+	// Freed pages are reused, and no pages are leaked.
+	free := len(tree.free)
+	for _, key := range keys[:100] {
+		tree.Insert(goavl.Int(key))
+	}
+	if len(tree.free) >= free {
+		t.Errorf("%d free pages before and %d after inserting\n", free, len(tree.free))
+	}
+	if err := tree.Sync(); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	verifyPages(t, tree)
+	verify(t, tree, keys)
+}
+
+// verifyPages checks that all pages of the file of the synced tree are either
+// used by its nodes or free.
+func verifyPages(t *testing.T, tree *Tree) {
+	t.Helper()
+	if used := int(tree.numPages) - 1 - len(tree.free); used != tree.Size() || len(tree.pending) != 0 {
+		t.Errorf("%d pages used by %d nodes, %d pending\n", used, tree.Size(), len(tree.pending))
+	}
+}
+
+// crash closes the file of tree without syncing it.
+func crash(tree *Tree) {
+	tree.f.Close()
+}
+
+func TestScanBounded(t *testing.T) {
+	tree := open(t, filepath.Join(t.TempDir(), "tree"), small)
+	defer tree.Close()
+	keys := rand.Perm(500)
+	for _, key := range keys {
+		tree.Insert(goavl.Int(key))
+	}
+
+	// Scans must evict nodes as they go, including the dirty ones, rather
+	// than when they are done.
+	next := 0
+	err := tree.AscendRange(nil, nil, func(key goavl.Item) bool {
+		if key != goavl.Int(next) {
+			t.Fatalf("AscendRange visited %v; expected %d\n", key, next)
+		}
+		next++
+		if tree.lru.Len() > small.CacheSize {
+			t.Fatalf("buffer pool holds %d nodes during a scan; expected at most %d\n",
+				tree.lru.Len(), small.CacheSize)
+		}
+		return true
+	})
+	if err != nil || next != len(keys) {
+		t.Errorf("AscendRange visited %d keys, %v; expected %d\n", next, err, len(keys))
+	}
+	if preOrder := tree.PreOrder(); len(preOrder) != len(keys) {
+		t.Errorf("PreOrder() returned %d keys; expected %d\n", len(preOrder), len(keys))
+	}
+	if err := tree.Sync(); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	sorted := make([]int, len(keys))
+	for i := range sorted {
+		sorted[i] = i
+	}
+	verify(t, tree, sorted)
+}
+
+func TestCrash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree")
+	tree := open(t, path, small)
+	keys := rand.Perm(200)
+	for _, key := range keys[:100] {
+		tree.Insert(goavl.Int(key))
+	}
+	if err := tree.Sync(); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+
+	// Modifications after the Sync reach the file as nodes are evicted from
+	// the buffer pool, but must not be visible after a crash.
+	for _, key := range keys[100:] {
+		tree.Insert(goavl.Int(key))
+	}
+	for _, key := range keys[:50] {
+		tree.Delete(goavl.Int(key))
+	}
+	crash(tree)
+
+	tree = open(t, path, small)
+	verify(t, tree, keys[:100])
+
+	// A torn header falls back to the previous one.
+	for _, key := range keys[100:] {
+		tree.Insert(goavl.Int(key))
+	}
+	if err := tree.Sync(); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	crash(tree)
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	if _, err := f.WriteAt([]byte("torn"), int64(tree.seq%2)*slotSize+offSeq); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	f.Close()
+
+	tree = open(t, path, small)
+	defer tree.Close()
+	verify(t, tree, keys[:100])
+}
+
+func TestErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Open(filepath.Join(dir, "tree"), goavl.IntCodec, &Options{PageSize: 100}); err == nil {
+		t.Errorf("\tExpected an error for a small page size!\n")
+	}
+
+	garbage := filepath.Join(dir, "garbage")
+	os.WriteFile(garbage, []byte("not a tree"), 0666)
+	if _, err := Open(garbage, goavl.IntCodec, nil); err == nil {
+		t.Errorf("\tExpected an error for opening an invalid file!\n")
+	} else {
+		t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+	}
+
+	tree, err := Open(filepath.Join(dir, "strings"), goavl.StringCodec, small)
+	if err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	defer tree.Close()
+	if err := tree.Insert(goavl.String(make([]byte, MinPageSize))); err == nil {
+		t.Errorf("\tExpected an error for a key larger than a page!\n")
+	} else {
+		t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+	}
+	if err := tree.Insert(goavl.Int(1)); err == nil {
+		t.Errorf("\tExpected an error for a key that cannot be encoded!\n")
+	}
+	if _, err := tree.Min(); err == nil {
+		t.Errorf("\tExpected an error for an empty tree!\n")
+	}
+}
@@ -0,0 +1,297 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package paged
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/ckatsak/goavl"
+)
+
+// Layout of the header slots, two of which are stored in page 0, at offsets 0
+// and slotSize. Each slot fits in a single disk sector, so that writing one
+// of them cannot tear the other.
+const (
+	slotSize   = 512
+	headerSize = 48
+
+	offMagic    = 0  // [8]byte
+	offSeq      = 8  // uint64
+	offPageSize = 16 // uint32
+	offRoot     = 20 // uint64
+	offSize     = 28 // uint64
+	offNumPages = 36 // uint64
+	offCRC      = 44 // uint32, of the preceding bytes
+)
+
+var magic = []byte("goavlpg\x01")
+
+// Layout of the node pages.
+const (
+	nodeHeaderSize = 24
+
+	offLeft   = 0  // uint64
+	offRight  = 8  // uint64
+	offHeight = 16 // uint32
+	offKeyLen = 20 // uint32
+	offKey    = nodeHeaderSize
+)
+
+// header is the decoded contents of a header slot.
+type header struct {
+	seq      uint64
+	pageSize int
+	root     uint64
+	size     int
+	numPages uint64
+}
+
+// encodeHeader encodes h into a header slot.
+func encodeHeader(h header) []byte {
+	b := make([]byte, headerSize)
+	copy(b[offMagic:], magic)
+	binary.BigEndian.PutUint64(b[offSeq:], h.seq)
+	binary.BigEndian.PutUint32(b[offPageSize:], uint32(h.pageSize))
+	binary.BigEndian.PutUint64(b[offRoot:], h.root)
+	binary.BigEndian.PutUint64(b[offSize:], uint64(h.size))
+	binary.BigEndian.PutUint64(b[offNumPages:], h.numPages)
+	binary.BigEndian.PutUint32(b[offCRC:], crc32.ChecksumIEEE(b[:offCRC]))
+	return b
+}
+
+// decodeHeader decodes a header slot, and reports whether it is valid.
+func decodeHeader(b []byte) (header, bool) {
+	if !bytes.Equal(b[offMagic:offMagic+len(magic)], magic) ||
+		binary.BigEndian.Uint32(b[offCRC:]) != crc32.ChecksumIEEE(b[:offCRC]) {
+		return header{}, false
+	}
+	return header{
+		seq:      binary.BigEndian.Uint64(b[offSeq:]),
+		pageSize: int(binary.BigEndian.Uint32(b[offPageSize:])),
+		root:     binary.BigEndian.Uint64(b[offRoot:]),
+		size:     int(binary.BigEndian.Uint64(b[offSize:])),
+		numPages: binary.BigEndian.Uint64(b[offNumPages:]),
+	}, true
+}
+
+// readHeader reads both header slots from f, and returns the valid one that
+// was written last.
+func readHeader(f io.ReaderAt) (header, error) {
+	b := make([]byte, 2*slotSize)
+	if _, err := f.ReadAt(b, 0); err != nil && err != io.EOF {
+		return header{}, err
+	}
+	h0, ok0 := decodeHeader(b[:headerSize])
+	h1, ok1 := decodeHeader(b[slotSize : slotSize+headerSize])
+	switch {
+	case ok0 && (!ok1 || h0.seq > h1.seq):
+		return h0, nil
+	case ok1:
+		return h1, nil
+	}
+	return header{}, fmt.Errorf("No valid header found")
+}
+
+// node is a treeNode stored in a page. The children are referred to by the
+// numbers of their pages, 0 standing for none.
+type node struct {
+	id          uint64
+	key         goavl.Item
+	raw         []byte // the encoded key
+	left, right uint64
+	h           int
+	dirty       bool // modified since it was last written
+	elem        *list.Element
+}
+
+// ioError wraps the I/O errors that occur while accessing pages, which are
+// propagated by panicking, and recovered by Tree.do.
+type ioError struct {
+	err error
+}
+
+// read reads the node stored in page id, bypassing the buffer pool.
+func (t *Tree) read(id uint64) *node {
+	page := make([]byte, t.pageSize)
+	if _, err := t.f.ReadAt(page, int64(id)*int64(t.pageSize)); err != nil {
+		panic(ioError{fmt.Errorf("Reading page %d: %v", id, err)})
+	}
+	n := &node{
+		id:    id,
+		left:  binary.BigEndian.Uint64(page[offLeft:]),
+		right: binary.BigEndian.Uint64(page[offRight:]),
+		h:     int(binary.BigEndian.Uint32(page[offHeight:])),
+	}
+	keyLen := int(binary.BigEndian.Uint32(page[offKeyLen:]))
+	if keyLen > t.pageSize-offKey {
+		panic(ioError{fmt.Errorf("Corrupted page %d: key of %d bytes", id, keyLen)})
+	}
+	n.raw = page[offKey : offKey+keyLen]
+	key, err := t.codec.Decode(n.raw)
+	if err != nil {
+		panic(ioError{fmt.Errorf("Decoding the key of page %d: %v", id, err)})
+	}
+	n.key = key
+	return n
+}
+
+// write writes n to its page.
+func (t *Tree) write(n *node) {
+	page := make([]byte, t.pageSize)
+	binary.BigEndian.PutUint64(page[offLeft:], n.left)
+	binary.BigEndian.PutUint64(page[offRight:], n.right)
+	binary.BigEndian.PutUint32(page[offHeight:], uint32(n.h))
+	binary.BigEndian.PutUint32(page[offKeyLen:], uint32(len(n.raw)))
+	copy(page[offKey:], n.raw)
+	if _, err := t.f.WriteAt(page, int64(n.id)*int64(t.pageSize)); err != nil {
+		panic(ioError{fmt.Errorf("Writing page %d: %v", n.id, err)})
+	}
+	n.dirty = false
+}
+
+// get returns the node stored in page id, or nil if id is 0, through the
+// buffer pool.
+func (t *Tree) get(id uint64) *node {
+	if id == 0 {
+		return nil
+	}
+	if elem, ok := t.cache[id]; ok {
+		t.lru.MoveToFront(elem)
+		return elem.Value.(*node)
+	}
+	n := t.read(id)
+	t.cache[id] = t.lru.PushFront(n)
+	n.elem = t.cache[id]
+	return n
+}
+
+// height returns the height of the node stored in page id.
+func (t *Tree) height(id uint64) int {
+	if id == 0 {
+		return 0
+	}
+	return t.get(id).h
+}
+
+// newNode allocates a page for a new node holding key, whose encoding is raw.
+func (t *Tree) newNode(key goavl.Item, raw []byte) *node {
+	n := &node{id: t.alloc(), key: key, raw: raw, h: 1, dirty: true}
+	n.elem = t.lru.PushFront(n)
+	t.cache[n.id] = n.elem
+	return n
+}
+
+// modify must be called before modifying n. Pages that were allocated since
+// the last Sync are modified in place; the rest are part of the last synced
+// state of the tree, so n is copied to a newly allocated page instead, and the
+// caller must then refer to it by its new id.
+func (t *Tree) modify(n *node) {
+	n.dirty = true
+	if t.fresh[n.id] {
+		return
+	}
+	t.pending = append(t.pending, n.id)
+	delete(t.cache, n.id)
+	n.id = t.alloc()
+	t.cache[n.id] = n.elem
+}
+
+// release frees the page of n, which has been removed from the tree.
+func (t *Tree) release(n *node) {
+	t.lru.Remove(n.elem)
+	delete(t.cache, n.id)
+	if t.fresh[n.id] {
+		delete(t.fresh, n.id)
+		t.free = append(t.free, n.id)
+	} else {
+		t.pending = append(t.pending, n.id)
+	}
+}
+
+// alloc allocates a page, reusing a free one if possible.
+func (t *Tree) alloc() uint64 {
+	var id uint64
+	if len(t.free) > 0 {
+		id, t.free = t.free[len(t.free)-1], t.free[:len(t.free)-1]
+	} else {
+		id = t.numPages
+		t.numPages++
+	}
+	t.fresh[id] = true
+	return id
+}
+
+// trim evicts the least recently used nodes from the buffer pool, until it
+// holds at most as many as its capacity, writing the dirty ones to their
+// pages.
+func (t *Tree) trim() {
+	for t.lru.Len() > t.capacity {
+		n := t.lru.Remove(t.lru.Back()).(*node)
+		delete(t.cache, n.id)
+		if n.dirty {
+			t.write(n)
+		}
+	}
+}
+
+// sync writes all dirty nodes to their pages and then the header, waiting
+// for the file to reach stable storage after each step.
+func (t *Tree) sync() {
+	for elem := t.lru.Front(); elem != nil; elem = elem.Next() {
+		if n := elem.Value.(*node); n.dirty {
+			t.write(n)
+		}
+	}
+	if err := t.f.Sync(); err != nil {
+		panic(ioError{err})
+	}
+
+	h := header{seq: t.seq + 1, pageSize: t.pageSize, root: t.root, size: t.size, numPages: t.numPages}
+	if _, err := t.f.WriteAt(encodeHeader(h), int64(h.seq%2)*slotSize); err != nil {
+		panic(ioError{err})
+	}
+	if err := t.f.Sync(); err != nil {
+		panic(ioError{err})
+	}
+	t.seq = h.seq
+
+	t.free = append(t.free, t.pending...)
+	t.pending = nil
+	t.fresh = make(map[uint64]bool)
+}
+
+// reclaim rebuilds the list of free pages, as those that are not reachable
+// from the root, bypassing the buffer pool.
+func (t *Tree) reclaim() {
+	reachable := make([]bool, t.numPages)
+	stack := []uint64{t.root}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == 0 {
+			continue
+		}
+		if id >= t.numPages || reachable[id] {
+			panic(ioError{fmt.Errorf("Corrupted file: invalid reference to page %d", id)})
+		}
+		reachable[id] = true
+		n := t.read(id)
+		stack = append(stack, n.left, n.right)
+	}
+	for id := t.numPages - 1; id > 0; id-- {
+		if !reachable[id] {
+			t.free = append(t.free, id)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import "fmt"
+
+// PopMin removes the minimum key from the AVL tree and returns it, along with
+// an error value, which is non-nil if the tree is empty. Unlike Min followed
+// by Delete, it descends the tree only once.
+func (t *Tree) PopMin() (Item, error) {
+	if t.root == nil {
+		return nil, fmt.Errorf("Empty tree")
+	}
+	var min *treeNode
+	t.root, min = t.root.subtreeDeleteMin(&t.stats, deleteRotation)
+	t.size--
+	t.keyBytes -= keySize(min.key)
+	t.mods++
+	return min.key, nil
+}
+
+// PopMax removes the maximum key from the AVL tree and returns it, along with
+// an error value, which is non-nil if the tree is empty. Unlike Max followed
+// by Delete, it descends the tree only once.
+func (t *Tree) PopMax() (Item, error) {
+	if t.root == nil {
+		return nil, fmt.Errorf("Empty tree")
+	}
+	var max *treeNode
+	t.root, max = t.root.subtreeDeleteMax(&t.stats, deleteRotation)
+	t.size--
+	t.keyBytes -= keySize(max.key)
+	t.mods++
+	return max.key, nil
+}
+
+// subtreeDescend calls visit for each Item in the AVL subtree rooted with n,
+// in descending order, until visit returns false. It returns false if visit
+// stopped the traversal.
+func (n *treeNode) subtreeDescend(visit func(Item) bool) bool {
+	if n == nil {
+		return true
+	}
+	return n.right.subtreeDescend(visit) && visit(n.key) && n.left.subtreeDescend(visit)
+}
+
+// TopK returns the k greatest keys in the AVL tree, in descending order, or
+// all of them if the tree holds fewer than k keys.
+func (t *Tree) TopK(k int) []Item {
+	if k <= 0 {
+		return []Item{}
+	}
+	ret := make([]Item, 0, min(k, t.size))
+	t.root.subtreeDescend(func(item Item) bool {
+		ret = append(ret, item)
+		return len(ret) < k
+	})
+	return ret
+}
+
+// BottomK returns the k least keys in the AVL tree, in ascending order, or
+// all of them if the tree holds fewer than k keys.
+func (t *Tree) BottomK(k int) []Item {
+	if k <= 0 {
+		return []Item{}
+	}
+	ret := make([]Item, 0, min(k, t.size))
+	t.AscendRange(nil, nil, func(item Item) bool {
+		ret = append(ret, item)
+		return len(ret) < k
+	})
+	return ret
+}
+
+// Handle refers to an element of a PriorityQueue, i.e. a Value along with its
+// priority. It is returned by Push and can be used to update the priority of
+// the element or to remove it from the queue.
+type Handle struct {
+	Value    interface{}
+	priority Item
+	seq      uint64
+	pq       *PriorityQueue
+}
+
+// Priority returns the priority of the element that h refers to.
+func (h *Handle) Priority() Item {
+	return h.priority
+}
+
+// pqEntry is the Item stored in the tree of a PriorityQueue. Entries are
+// ordered by their priorities, and ties are broken by the order they were
+// pushed or updated in.
+type pqEntry struct {
+	*Handle
+}
+
+func (e pqEntry) Equal(to Item) bool {
+	return e.Handle == to.(pqEntry).Handle
+}
+
+func (e pqEntry) Less(than Item) bool {
+	f := than.(pqEntry)
+	if e.priority.Less(f.priority) {
+		return true
+	}
+	if e.priority.Equal(f.priority) {
+		return e.seq < f.seq
+	}
+	return false
+}
+
+// PriorityQueue is a double-ended priority queue backed by an AVL tree.
+// Unlike a Tree, it may hold multiple elements of equal priorities; among
+// them, PopMin returns the earliest pushed, while PopMax returns the latest.
+type PriorityQueue struct {
+	tree Tree
+	seq  uint64
+}
+
+// NewPriorityQueue creates a new empty PriorityQueue.
+func NewPriorityQueue() *PriorityQueue {
+	return &PriorityQueue{}
+}
+
+// Len returns the current number of elements in the PriorityQueue.
+func (pq *PriorityQueue) Len() int {
+	return pq.tree.Size()
+}
+
+// Push adds value to the PriorityQueue with the given priority, and returns a
+// Handle that refers to it.
+func (pq *PriorityQueue) Push(priority Item, value interface{}) *Handle {
+	h := &Handle{Value: value, priority: priority, seq: pq.seq, pq: pq}
+	pq.seq++
+	pq.tree.Insert(pqEntry{h}) // cannot fail, since seq is unique
+	return h
+}
+
+// Update changes the priority of the element that h refers to. It returns an
+// error value, which is non-nil if the element is not in the PriorityQueue.
+func (pq *PriorityQueue) Update(h *Handle, priority Item) error {
+	if err := pq.Remove(h); err != nil {
+		return err
+	}
+	h.priority, h.seq, h.pq = priority, pq.seq, pq
+	pq.seq++
+	pq.tree.Insert(pqEntry{h})
+	return nil
+}
+
+// Remove removes the element that h refers to from the PriorityQueue. It
+// returns an error value, which is non-nil if the element is not in the
+// PriorityQueue.
+func (pq *PriorityQueue) Remove(h *Handle) error {
+	if h.pq != pq {
+		return fmt.Errorf("Element not in the priority queue: %v", h.Value)
+	}
+	pq.tree.Delete(pqEntry{h})
+	h.pq = nil
+	return nil
+}
+
+// PeekMin returns the element of minimum priority, and an error value which
+// is non-nil if the PriorityQueue is empty.
+func (pq *PriorityQueue) PeekMin() (*Handle, error) {
+	return pq.handle(pq.tree.Min())
+}
+
+// PeekMax returns the element of maximum priority, and an error value which
+// is non-nil if the PriorityQueue is empty.
+func (pq *PriorityQueue) PeekMax() (*Handle, error) {
+	return pq.handle(pq.tree.Max())
+}
+
+// PopMin removes and returns the element of minimum priority, and an error
+// value which is non-nil if the PriorityQueue is empty.
+func (pq *PriorityQueue) PopMin() (*Handle, error) {
+	h, err := pq.handle(pq.tree.PopMin())
+	if err == nil {
+		h.pq = nil
+	}
+	return h, err
+}
+
+// PopMax removes and returns the element of maximum priority, and an error
+// value which is non-nil if the PriorityQueue is empty.
+func (pq *PriorityQueue) PopMax() (*Handle, error) {
+	h, err := pq.handle(pq.tree.PopMax())
+	if err == nil {
+		h.pq = nil
+	}
+	return h, err
+}
+
+// handle converts the results of the tree operations into a Handle.
+func (pq *PriorityQueue) handle(item Item, err error) (*Handle, error) {
+	if err != nil {
+		return nil, err
+	}
+	return item.(pqEntry).Handle, nil
+}
+
+// Heap adapts a PriorityQueue to heap.Interface, so that it can be handed to
+// code that expects one: pushing Items into it with heap.Push and popping
+// them with heap.Pop yields them in ascending order, equal ones in the order
+// they were pushed.
+//
+// Since the elements are ordered by the AVL tree rather than by an array, the
+// i-th element of the Heap is always its i-th least, which makes it a valid
+// binary heap at all times: Less(i, j) is simply i < j, so that container/heap
+// never needs to move elements, and heap.Init and heap.Fix do nothing. Swap
+// only records which element the following Pop should remove, since
+// container/heap swaps the element to be removed with the last one before
+// calling Pop. Thus, heap.Pop removes the least element and heap.Remove(h, i)
+// the i-th least one, in O(log n) time.
+type Heap struct {
+	pq      PriorityQueue
+	swapped bool // whether Pop removes element pop, instead of the last one
+	pop     int
+}
+
+// NewHeap creates a new empty Heap.
+func NewHeap() *Heap {
+	return &Heap{}
+}
+
+// Len implements heap.Interface.
+func (h *Heap) Len() int {
+	return h.pq.Len()
+}
+
+// Less implements heap.Interface; see Heap.
+func (h *Heap) Less(i, j int) bool {
+	return i < j
+}
+
+// Swap implements heap.Interface; see Heap.
+func (h *Heap) Swap(i, j int) {
+	switch last := h.Len() - 1; {
+	case j == last:
+		h.swapped, h.pop = true, i
+	case i == last:
+		h.swapped, h.pop = true, j
+	}
+}
+
+// Push implements heap.Interface. x must be an Item; multiple equal Items may
+// be pushed.
+func (h *Heap) Push(x interface{}) {
+	h.pq.Push(x.(Item), nil)
+}
+
+// Pop implements heap.Interface, removing the element that the preceding Swap
+// moved to the end; see Heap. It panics if the Heap is empty.
+func (h *Heap) Pop() interface{} {
+	i := h.Len() - 1
+	if h.swapped {
+		i, h.swapped = h.pop, false
+	}
+	n := h.pq.tree.root.subtreeSelect(i)
+	if n == nil {
+		panic("goavl: Pop from an empty Heap")
+	}
+	e := n.key.(pqEntry).Handle
+	h.pq.Remove(e)
+	return e.priority
+}
+
+// Peek returns the minimum Item without removing it, and an error value which
+// is non-nil if the Heap is empty.
+func (h *Heap) Peek() (Item, error) {
+	e, err := h.pq.PeekMin()
+	if err != nil {
+		return nil, err
+	}
+	return e.priority, nil
+}
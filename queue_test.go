@@ -0,0 +1,197 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"container/heap"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestPopMinMax(t *testing.T) {
+	tree := NewTree()
+	size := 1 << 12
+	rands := populateTreeAndSlice(t, tree, uint(size))
+	sort.Ints(rands)
+
+	for i := 0; i < size/2; i++ {
+		min, err := tree.PopMin()
+		if err != nil {
+			t.Fatalf("\t%v\n", err)
+		}
+		if min != Integer(rands[i]) {
+			t.Errorf("tree.PopMin() = %d; expected %d\n", min, rands[i])
+		}
+		max, err := tree.PopMax()
+		if err != nil {
+			t.Fatalf("\t%v\n", err)
+		}
+		if max != Integer(rands[size-1-i]) {
+			t.Errorf("tree.PopMax() = %d; expected %d\n", max, rands[size-1-i])
+		}
+		if tree.Size() != size-2*(i+1) {
+			t.Errorf("tree.Size() = %d; expected %d\n", tree.Size(), size-2*(i+1))
+		}
+		if bound := maxHeight(tree.Size()); tree.Height() > bound {
+			t.Fatalf("tree.Height() = %d exceeds the AVL bound %d\n", tree.Height(), bound)
+		}
+	}
+
+	if _, err := tree.PopMin(); err == nil {
+		t.Errorf("\tExpected an error!\n")
+	}
+	if _, err := tree.PopMax(); err == nil {
+		t.Errorf("\tExpected an error!\n")
+	}
+}
+
+func TestTopBottomK(t *testing.T) {
+	tree := NewTree()
+	for _, key := range []Integer{9, 5, 10, 0, 6, 11, -1, 1, 2} {
+		if err := tree.Insert(key); err != nil {
+			t.Errorf("\t%v\n", err)
+		}
+	}
+	for _, tc := range []struct {
+		got      []Item
+		expected []Integer
+	}{
+		{tree.TopK(3), []Integer{11, 10, 9}},
+		{tree.BottomK(3), []Integer{-1, 0, 1}},
+		{tree.TopK(0), []Integer{}},
+		{tree.BottomK(-1), []Integer{}},
+		{tree.TopK(100), []Integer{11, 10, 9, 6, 5, 2, 1, 0, -1}},
+	} {
+		if len(tc.got) != len(tc.expected) {
+			t.Errorf("got %v; expected %v\n", tc.got, tc.expected)
+			continue
+		}
+		for i := range tc.got {
+			if tc.got[i] != tc.expected[i] {
+				t.Errorf("got %v; expected %v\n", tc.got, tc.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestPriorityQueue(t *testing.T) {
+	pq := NewPriorityQueue()
+	a := pq.Push(Integer(5), "a")
+	b := pq.Push(Integer(3), "b")
+	c := pq.Push(Integer(5), "c")
+	d := pq.Push(Integer(8), "d")
+	if pq.Len() != 4 {
+		t.Errorf("pq.Len() = %d; expected 4\n", pq.Len())
+	}
+
+	if h, _ := pq.PeekMin(); h != b {
+		t.Errorf("pq.PeekMin() = %v; expected b\n", h.Value)
+	}
+	if h, _ := pq.PeekMax(); h != d {
+		t.Errorf("pq.PeekMax() = %v; expected d\n", h.Value)
+	}
+
+	// Decrease the key of c below b's, and increase the key of b above d's.
+	if err := pq.Update(c, Integer(1)); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if err := pq.Update(b, Integer(9)); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if c.Priority() != Integer(1) {
+		t.Errorf("c.Priority() = %v; expected 1\n", c.Priority())
+	}
+	if err := pq.Remove(d); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if err := pq.Remove(d); err == nil {
+		t.Errorf("\tExpected an error for removing d twice!\n")
+	}
+	if err := pq.Update(d, Integer(0)); err == nil {
+		t.Errorf("\tExpected an error for updating a removed element!\n")
+	}
+
+	for _, expected := range []*Handle{c, a} {
+		if h, err := pq.PopMin(); err != nil || h != expected {
+			t.Errorf("pq.PopMin() = %v, %v; expected %v\n", h, err, expected.Value)
+		}
+	}
+	if h, err := pq.PopMax(); err != nil || h != b {
+		t.Errorf("pq.PopMax() = %v, %v; expected b\n", h, err)
+	}
+	if _, err := pq.PopMin(); err == nil {
+		t.Errorf("\tExpected an error!\n")
+	}
+	if err := pq.Remove(a); err == nil {
+		t.Errorf("\tExpected an error for removing a popped element!\n")
+	}
+}
+
+func TestPriorityQueueTies(t *testing.T) {
+	pq := NewPriorityQueue()
+	for _, v := range []string{"first", "second", "third"} {
+		pq.Push(Integer(0), v)
+	}
+	if h, _ := pq.PopMin(); h.Value != "first" {
+		t.Errorf("pq.PopMin() = %v; expected first\n", h.Value)
+	}
+	if h, _ := pq.PopMax(); h.Value != "third" {
+		t.Errorf("pq.PopMax() = %v; expected third\n", h.Value)
+	}
+}
+
+func TestHeap(t *testing.T) {
+	h := NewHeap()
+	var _ heap.Interface = h
+	if _, err := h.Peek(); err == nil {
+		t.Errorf("\tExpected an error for peeking into an empty heap!\n")
+	} else {
+		t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+	}
+
+	rands := rand.Perm(1000)
+	for _, r := range rands[:500] {
+		h.Push(Integer(r % 500)) // duplicates are allowed
+	}
+	heap.Init(h)
+	for _, r := range rands[500:] {
+		heap.Push(h, Integer(r%500))
+	}
+	if x, err := h.Peek(); err != nil || x != Integer(0) {
+		t.Errorf("h.Peek() = %v, %v; expected 0\n", x, err)
+	}
+
+	// Remove the greatest and the third least elements.
+	if x := heap.Remove(h, h.Len()-1); x != Integer(499) {
+		t.Errorf("heap.Remove(h, %d) = %v; expected 499\n", h.Len(), x)
+	}
+	if x := heap.Remove(h, 2); x != Integer(1) {
+		t.Errorf("heap.Remove(h, 2) = %v; expected 1\n", x)
+	}
+	heap.Fix(h, 0)
+
+	expected := []Integer{0, 0, 1}
+	for i := 2; i < 499; i++ {
+		expected = append(expected, Integer(i), Integer(i))
+	}
+	expected = append(expected, 499)
+	var popped []Integer
+	for h.Len() > 0 {
+		popped = append(popped, heap.Pop(h).(Integer))
+	}
+	if !reflect.DeepEqual(popped, expected) {
+		t.Errorf("heap.Pop() returned %v; expected %v\n", popped, expected)
+	}
+	if _, err := h.Peek(); h.Len() != 0 || err == nil {
+		t.Errorf("h.Len() = %d; expected an empty heap\n", h.Len())
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+// join joins the AVL subtrees l and r with the standalone treeNode m, into a
+// single AVL subtree, whose new root it returns. All keys in l must be less
+// than the key of m, and all keys in r must be greater than it. It takes
+// O(|l.height() - r.height()|) time.
+func join(l, m, r *treeNode, c *counters) *treeNode {
+	switch {
+	case l.height() > r.height()+1:
+		l.right = join(l.right, m, r, c)
+		return l.rebalance(c, joinRotation)
+	case r.height() > l.height()+1:
+		r.left = join(l, m, r.left, c)
+		return r.rebalance(c, joinRotation)
+	}
+	m.left, m.right = l, r
+	m.update(c.aug)
+	return m
+}
+
+// join2 joins the AVL subtrees l and r into a single AVL subtree, whose new
+// root it returns. All keys in l must be less than all keys in r.
+func join2(l, r *treeNode, c *counters) *treeNode {
+	if l == nil {
+		return r
+	}
+	if r == nil {
+		return l
+	}
+	r, min := r.subtreeDeleteMin(c, joinRotation)
+	return join(l, min, r, c)
+}
+
+// split splits the AVL subtree rooted with n into two AVL subtrees, the first
+// of which holds all keys that are less than key and the second all the rest.
+// It takes O(log n) time.
+func (n *treeNode) split(key Item, c *counters) (*treeNode, *treeNode) {
+	if n == nil {
+		return nil, nil
+	}
+	left, right := n.left, n.right
+	if c.less(n.key, key) {
+		rl, rr := right.split(key, c)
+		return join(left, n, rl, c), rr
+	}
+	ll, lr := left.split(key, c)
+	return ll, join(lr, n, right, c)
+}
+
+// splitRange splits the AVL subtree rooted with n into three AVL subtrees,
+// holding the keys that are less than lo, in [lo, hi), and not less than hi,
+// respectively. A nil lo or hi leaves the range unbounded on that side.
+func (n *treeNode) splitRange(lo, hi Item, c *counters) (*treeNode, *treeNode, *treeNode) {
+	var left, mid, right *treeNode = nil, n, nil
+	if lo != nil {
+		left, mid = n.split(lo, c)
+	}
+	if hi != nil {
+		mid, right = mid.split(hi, c)
+	}
+	return left, mid, right
+}
+
+// ExtractRange removes all keys that are greater than or equal to lo and less
+// than hi from the AVL tree, and returns them as a new AVL tree. A nil lo or
+// hi leaves the range unbounded on that side.
+//
+// The tree is split and joined back in O(log n) time, instead of removing the
+// keys one by one; since each treeNode keeps the size of its subtree, the
+// extracted keys are counted in O(1) time.
+func (t *Tree) ExtractRange(lo, hi Item) *Tree {
+	left, mid, right := t.root.splitRange(lo, hi, &t.stats)
+	t.root = join2(left, right, &t.stats)
+	extracted := &Tree{root: mid, size: mid.subtreeSize(), keyBytes: mid.subtreeKeyBytes()}
+	extracted.stats.aug = t.stats.aug
+	t.size -= extracted.size
+	t.keyBytes -= extracted.keyBytes
+	t.mods++
+	return extracted
+}
+
+// DeleteRange removes all keys that are greater than or equal to lo and less
+// than hi from the AVL tree, and returns their number. A nil lo or hi leaves
+// the range unbounded on that side. It takes O(log n) time, as ExtractRange.
+func (t *Tree) DeleteRange(lo, hi Item) int {
+	return t.ExtractRange(lo, hi).Size()
+}
@@ -0,0 +1,143 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// verifyAVL checks that the subtree rooted with n is a valid AVL tree, i.e.
+// that its keys are ordered, its heights and sizes are correct and it is
+// balanced. It returns its number of nodes.
+func verifyAVL(t *testing.T, n *treeNode) int {
+	t.Helper()
+	if n == nil {
+		return 0
+	}
+	if n.left != nil && !n.left.subtreeMax().key.Less(n.key) {
+		t.Fatalf("keys of the left subtree of %v are not less than it\n", n.key)
+	}
+	if n.right != nil && !n.key.Less(n.right.subtreeMin().key) {
+		t.Fatalf("keys of the right subtree of %v are not greater than it\n", n.key)
+	}
+	if h := 1 + max(n.left.height(), n.right.height()); n.h != h {
+		t.Fatalf("height of %v is %d; expected %d\n", n.key, n.h, h)
+	}
+	if bal := n.balanceFactor(); bal < -1 || bal > 1 {
+		t.Fatalf("balance factor of %v is %d\n", n.key, bal)
+	}
+	size := 1 + verifyAVL(t, n.left) + verifyAVL(t, n.right)
+	if n.size != size {
+		t.Fatalf("size of %v is %d; expected %d\n", n.key, n.size, size)
+	}
+	if b := keySize(n.key) + n.left.subtreeKeyBytes() + n.right.subtreeKeyBytes(); n.keyBytes != b {
+		t.Fatalf("key bytes of %v are %d; expected %d\n", n.key, n.keyBytes, b)
+	}
+	return size
+}
+
+// verifyTree checks that tree is a valid AVL tree holding exactly the keys.
+func verifyTree(t *testing.T, tree *Tree, keys []int) {
+	t.Helper()
+	if size := verifyAVL(t, tree.root); size != tree.Size() {
+		t.Fatalf("tree has %d nodes, but tree.Size() = %d\n", size, tree.Size())
+	}
+	sorted := append([]int{}, keys...)
+	sort.Ints(sorted)
+	traversal := inOrder(t, tree.root)
+	if len(traversal) != len(sorted) {
+		t.Fatalf("tree has %d keys; expected %d\n", len(traversal), len(sorted))
+	}
+	verifyTraversal(t, traversal, sorted)
+}
+
+func TestJoin(t *testing.T) {
+	c := &counters{}
+	for ls := 0; ls < 40; ls++ {
+		for rs := 0; rs < 40; rs += 3 {
+			l, r := NewTree(), NewTree()
+			keys := []int{}
+			for i := 0; i < ls; i++ {
+				l.Insert(Integer(i))
+				keys = append(keys, i)
+			}
+			for i := 0; i < rs; i++ {
+				r.Insert(Integer(ls + 1 + i))
+				keys = append(keys, ls+1+i)
+			}
+			joined := &Tree{root: join(l.root, newNode(Integer(ls), nil), r.root, c), size: ls + rs + 1}
+			verifyTree(t, joined, append(keys, ls))
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tree := NewTree()
+	rands := rand.Perm(1000)
+	for _, r := range rands {
+		tree.Insert(Integer(2 * r))
+	}
+	c := &counters{}
+	for key := -1; key <= 2000; key += 37 {
+		l, r := tree.root.split(Integer(key), c)
+		nl, nr := verifyAVL(t, l), verifyAVL(t, r)
+		if expected := (key + 1) / 2; nl != expected || nr != 1000-expected {
+			t.Fatalf("split(%d) produced trees of %d and %d keys; expected %d and %d\n",
+				key, nl, nr, expected, 1000-expected)
+		}
+		if l != nil && !l.subtreeMax().key.Less(Integer(key)) {
+			t.Fatalf("split(%d): left tree holds %v\n", key, l.subtreeMax().key)
+		}
+		if r != nil && r.subtreeMin().key.Less(Integer(key)) {
+			t.Fatalf("split(%d): right tree holds %v\n", key, r.subtreeMin().key)
+		}
+		tree.root = join2(l, r, c)
+	}
+}
+
+func TestDeleteRange(t *testing.T) {
+	for iter := 0; iter < 100; iter++ {
+		tree := NewTree()
+		size := rand.Intn(500)
+		keys := []int{}
+		for _, r := range rand.Perm(size) {
+			tree.Insert(Integer(r))
+			keys = append(keys, r)
+		}
+		lo, hi := rand.Intn(size+10)-5, rand.Intn(size+10)-5
+		var loItem, hiItem Item = Integer(lo), Integer(hi)
+		switch iter % 10 {
+		case 0:
+			loItem, lo = nil, -1<<31
+		case 1:
+			hiItem, hi = nil, 1<<31
+		}
+
+		kept, removed := []int{}, []int{}
+		for _, k := range keys {
+			if lo <= k && k < hi {
+				removed = append(removed, k)
+			} else {
+				kept = append(kept, k)
+			}
+		}
+
+		if iter%2 == 0 {
+			if n := tree.DeleteRange(loItem, hiItem); n != len(removed) {
+				t.Errorf("DeleteRange(%v, %v) = %d; expected %d\n", loItem, hiItem, n, len(removed))
+			}
+		} else {
+			extracted := tree.ExtractRange(loItem, hiItem)
+			verifyTree(t, extracted, removed)
+		}
+		verifyTree(t, tree, kept)
+	}
+}
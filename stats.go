@@ -0,0 +1,106 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+// Stats holds operational statistics about an AVL tree.
+//
+// The counters (comparisons and rotations) are cumulative since the creation
+// of the tree, while the rest of the fields describe its current shape.
+type Stats struct {
+	// Comparisons is the number of calls to Item.Less and Item.Equal that the
+	// tree has performed while inserting and deleting keys. Lookups and
+	// traversals are not accounted for, so that they never write to the tree.
+	Comparisons uint64
+
+	// InsertRotations and InsertDoubleRotations are the numbers of single
+	// and double rotations performed while rebalancing after insertions.
+	InsertRotations       uint64
+	InsertDoubleRotations uint64
+
+	// DeleteRotations and DeleteDoubleRotations are the numbers of single
+	// and double rotations performed while rebalancing after deletions.
+	DeleteRotations       uint64
+	DeleteDoubleRotations uint64
+
+	// JoinRotations and JoinDoubleRotations are the numbers of single and
+	// double rotations performed while joining subtrees, by ApplyBatch,
+	// ExtractRange and DeleteRange.
+	JoinRotations       uint64
+	JoinDoubleRotations uint64
+
+	// DepthHistogram holds the number of nodes found at each depth of the
+	// tree, the root being at depth 0.
+	DepthHistogram []int
+
+	// AvgPathLength and MaxPathLength are the average and maximum number of
+	// nodes visited by a successful search, over all keys in the tree.
+	AvgPathLength float64
+	MaxPathLength int
+
+	// HeightRatio is the ratio of the current height of the tree to the
+	// maximum height that an AVL tree of the same size may have.
+	HeightRatio float64
+}
+
+// Stats returns the operational statistics of the AVL tree. Computing the
+// shape-related fields requires a full traversal of the tree.
+func (t *Tree) Stats() Stats {
+	return newStats(t.root, t.size, &t.stats)
+}
+
+// newStats returns the Stats of the AVL subtree rooted with root, which holds
+// size keys, with the given counters.
+func newStats(root *treeNode, size int, c *counters) Stats {
+	s := Stats{
+		Comparisons:           c.comparisons,
+		InsertRotations:       c.insertRotations,
+		InsertDoubleRotations: c.insertDoubleRotations,
+		DeleteRotations:       c.deleteRotations,
+		DeleteDoubleRotations: c.deleteDoubleRotations,
+		JoinRotations:         c.joinRotations,
+		JoinDoubleRotations:   c.joinDoubleRotations,
+	}
+	if root == nil {
+		return s
+	}
+
+	s.DepthHistogram = make([]int, root.height())
+	root.subtreeDepths(0, s.DepthHistogram)
+
+	total := 0
+	for depth, count := range s.DepthHistogram {
+		total += (depth + 1) * count
+	}
+	s.AvgPathLength = float64(total) / float64(size)
+	s.MaxPathLength = len(s.DepthHistogram)
+	s.HeightRatio = float64(root.height()) / float64(maxHeight(size))
+	return s
+}
+
+// subtreeDepths adds the nodes of the AVL subtree rooted with n, found at
+// depth d, to the given histogram.
+func (n *treeNode) subtreeDepths(d int, hist []int) {
+	if n == nil {
+		return
+	}
+	hist[d]++
+	n.left.subtreeDepths(d+1, hist)
+	n.right.subtreeDepths(d+1, hist)
+}
+
+// maxHeight returns the maximum height that an AVL tree with size nodes may
+// have, i.e. the largest h for which the sparsest AVL tree of height h (the
+// Fibonacci tree) does not have more than size nodes.
+func maxHeight(size int) int {
+	h, prev, curr := 0, 0, 1 // curr is the minimum number of nodes for height h+1
+	for curr <= size {
+		h, prev, curr = h+1, curr, curr+prev+1
+	}
+	return h
+}
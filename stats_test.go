@@ -0,0 +1,141 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import "testing"
+
+func TestMaxHeight(t *testing.T) {
+	// Sizes of the Fibonacci trees of heights 0 to 6.
+	for h, size := range []int{0, 1, 2, 4, 7, 12, 20} {
+		if got := maxHeight(size); got != h {
+			t.Errorf("maxHeight(%d) = %d; expected %d\n", size, got, h)
+		}
+		if h > 0 {
+			if got := maxHeight(size - 1); got != h-1 {
+				t.Errorf("maxHeight(%d) = %d; expected %d\n", size-1, got, h-1)
+			}
+		}
+	}
+}
+
+func TestStatsEmpty(t *testing.T) {
+	tree := NewTree()
+	s := tree.Stats()
+	if s.Comparisons != 0 || s.DepthHistogram != nil || s.MaxPathLength != 0 {
+		t.Errorf("Stats() of empty tree = %+v; expected zero value\n", s)
+	}
+}
+
+func TestStatsRotations(t *testing.T) {
+	tree := NewTree()
+
+	// Ascending insertions only cause single (right right) rotations.
+	for _, key := range []Integer{1, 2, 3, 4, 5} {
+		if err := tree.Insert(key); err != nil {
+			t.Errorf("\t%v\n", err)
+		}
+	}
+	s := tree.Stats()
+	if s.InsertRotations != 2 || s.InsertDoubleRotations != 0 {
+		t.Errorf("single, double rotations = %d, %d; expected 2, 0\n",
+			s.InsertRotations, s.InsertDoubleRotations)
+	}
+
+	// A zig-zag causes a double (right left) rotation.
+	tree = NewTree()
+	for _, key := range []Integer{1, 3, 2} {
+		if err := tree.Insert(key); err != nil {
+			t.Errorf("\t%v\n", err)
+		}
+	}
+	s = tree.Stats()
+	if s.InsertRotations != 0 || s.InsertDoubleRotations != 1 {
+		t.Errorf("single, double rotations = %d, %d; expected 0, 1\n",
+			s.InsertRotations, s.InsertDoubleRotations)
+	}
+
+	// Deleting 1 from [2 1 3 4] leaves 2 unbalanced to the right.
+	tree = NewTree()
+	for _, key := range []Integer{2, 1, 3, 4} {
+		if err := tree.Insert(key); err != nil {
+			t.Errorf("\t%v\n", err)
+		}
+	}
+	if err := tree.Delete(Integer(1)); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	s = tree.Stats()
+	if s.DeleteRotations != 1 || s.DeleteDoubleRotations != 0 {
+		t.Errorf("single, double rotations = %d, %d; expected 1, 0\n",
+			s.DeleteRotations, s.DeleteDoubleRotations)
+	}
+	if s.Comparisons == 0 {
+		t.Errorf("\tExpected a non-zero number of comparisons!\n")
+	}
+}
+
+func TestStatsJoinRotations(t *testing.T) {
+	tree := NewTree()
+	for i := 0; i < 64; i++ {
+		if err := tree.Insert(Integer(i)); err != nil {
+			t.Errorf("\t%v\n", err)
+		}
+	}
+	before := tree.Stats()
+
+	// A small batch of insertions at one end of the tree is joined back
+	// into it, which must not be reported as rebalancing after deletions.
+	ops := make([]Op, 8)
+	for i := range ops {
+		ops[i] = Op{Kind: OpInsert, Key: Integer(64 + i)}
+	}
+	if _, err := tree.ApplyBatch(ops); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	s := tree.Stats()
+	if s.DeleteRotations != before.DeleteRotations ||
+		s.DeleteDoubleRotations != before.DeleteDoubleRotations {
+		t.Errorf("delete rotations = %d, %d; expected %d, %d\n",
+			s.DeleteRotations, s.DeleteDoubleRotations,
+			before.DeleteRotations, before.DeleteDoubleRotations)
+	}
+	if s.JoinRotations+s.JoinDoubleRotations == 0 {
+		t.Errorf("\tExpected a non-zero number of join rotations!\n")
+	}
+}
+
+func TestStatsShape(t *testing.T) {
+	tree := NewTree()
+	for i := 0; i < 7; i++ {
+		if err := tree.Insert(Integer(i)); err != nil {
+			t.Errorf("\t%v\n", err)
+		}
+	}
+
+	// 7 ascending keys result in a perfect tree of height 3.
+	s := tree.Stats()
+	expected := []int{1, 2, 4}
+	if len(s.DepthHistogram) != len(expected) {
+		t.Fatalf("DepthHistogram = %v; expected %v\n", s.DepthHistogram, expected)
+	}
+	for d := range expected {
+		if s.DepthHistogram[d] != expected[d] {
+			t.Errorf("DepthHistogram = %v; expected %v\n", s.DepthHistogram, expected)
+		}
+	}
+	if s.MaxPathLength != 3 {
+		t.Errorf("MaxPathLength = %d; expected 3\n", s.MaxPathLength)
+	}
+	if avg := float64(1*1+2*2+3*4) / 7; s.AvgPathLength != avg {
+		t.Errorf("AvgPathLength = %f; expected %f\n", s.AvgPathLength, avg)
+	}
+	if ratio := 3.0 / 4.0; s.HeightRatio != ratio {
+		t.Errorf("HeightRatio = %f; expected %f\n", s.HeightRatio, ratio)
+	}
+}
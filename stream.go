@@ -0,0 +1,102 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// maxRecordKey is the maximum size of an encoded key that RecordDecoder
+// accepts, protecting it against corrupted streams.
+const maxRecordKey = 1 << 26
+
+// RecordEncoder writes Records to an io.Writer, with their keys encoded by a
+// KeyCodec. Each Record is encoded as its sequence number and the length of
+// its encoded key, as unsigned varints, followed by its kind (one byte) and
+// its encoded key.
+type RecordEncoder struct {
+	w     io.Writer
+	codec KeyCodec
+}
+
+// NewRecordEncoder creates a new RecordEncoder that writes to w.
+func NewRecordEncoder(w io.Writer, codec KeyCodec) *RecordEncoder {
+	return &RecordEncoder{w: w, codec: codec}
+}
+
+// Encode writes rec, with a single call to the Write method of the
+// underlying io.Writer.
+func (e *RecordEncoder) Encode(rec Record) error {
+	raw, err := e.codec.Encode(rec.Key)
+	if err != nil {
+		return err
+	}
+	b := make([]byte, 0, 2*binary.MaxVarintLen64+1+len(raw))
+	b = binary.AppendUvarint(b, rec.Seq)
+	b = binary.AppendUvarint(b, uint64(len(raw)))
+	b = append(b, byte(rec.Kind))
+	b = append(b, raw...)
+	_, err = e.w.Write(b)
+	return err
+}
+
+// RecordDecoder reads Records from an io.Reader, as written by a
+// RecordEncoder. It may read more data than needed from the io.Reader.
+type RecordDecoder struct {
+	r     *bufio.Reader
+	codec KeyCodec
+}
+
+// NewRecordDecoder creates a new RecordDecoder that reads from r.
+func NewRecordDecoder(r io.Reader, codec KeyCodec) *RecordDecoder {
+	return &RecordDecoder{r: bufio.NewReader(r), codec: codec}
+}
+
+// Decode reads the next Record. It returns io.EOF if the stream ends cleanly
+// before it, and io.ErrUnexpectedEOF if the stream ends in the middle of it.
+func (d *RecordDecoder) Decode() (Record, error) {
+	var rec Record
+	seq, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return rec, err
+	}
+	length, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return rec, unexpected(err)
+	}
+	if length > maxRecordKey {
+		return rec, fmt.Errorf("Invalid key length: %d", length)
+	}
+	kind, err := d.r.ReadByte()
+	if err != nil {
+		return rec, unexpected(err)
+	}
+	raw := make([]byte, length)
+	if _, err := io.ReadFull(d.r, raw); err != nil {
+		return rec, unexpected(err)
+	}
+	key, err := d.codec.Decode(raw)
+	if err != nil {
+		return rec, err
+	}
+	rec.Seq, rec.Kind, rec.Key = seq, OpKind(kind), key
+	return rec, nil
+}
+
+// unexpected turns io.EOF, which means that a Record was truncated, into
+// io.ErrUnexpectedEOF.
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,63 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestRecordStream(t *testing.T) {
+	records := []Record{
+		{1, Op{OpInsert, String("a")}},
+		{2, Op{OpInsert, String("")}},
+		{300, Op{OpDelete, String("a\x00b")}},
+	}
+	var buf bytes.Buffer
+	enc := NewRecordEncoder(&buf, StringCodec)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			t.Fatalf("\t%v\n", err)
+		}
+	}
+	if err := enc.Encode(Record{4, Op{OpInsert, Int(1)}}); err == nil {
+		t.Errorf("\tExpected an error for a key that cannot be encoded!\n")
+	}
+	encoded := buf.Bytes()
+
+	dec := NewRecordDecoder(bytes.NewReader(encoded), StringCodec)
+	for _, expected := range records {
+		rec, err := dec.Decode()
+		if err != nil || rec != expected {
+			t.Errorf("Decode() = %v, %v; expected %v\n", rec, err, expected)
+		}
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("Decode() at the end = %v; expected %v\n", err, io.EOF)
+	}
+
+	// Truncated streams are reported as such.
+	for n := 1; n < len(encoded); n++ {
+		dec := NewRecordDecoder(bytes.NewReader(encoded[:n]), StringCodec)
+		var err error
+		for err == nil {
+			_, err = dec.Decode()
+		}
+		if err != io.EOF && err != io.ErrUnexpectedEOF {
+			t.Errorf("Decode() of %d bytes = %v\n", n, err)
+		}
+	}
+	dec = NewRecordDecoder(bytes.NewReader(encoded[:len(encoded)-1]), StringCodec)
+	dec.Decode()
+	dec.Decode()
+	if _, err := dec.Decode(); err != io.ErrUnexpectedEOF {
+		t.Errorf("Decode() of a truncated record = %v; expected %v\n", err, io.ErrUnexpectedEOF)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"fmt"
+	"sort"
+)
+
+// fromSorted creates a new AVL tree out of keys, which must be sorted in
+// strictly ascending order, in O(len(keys)) time.
+func fromSorted(keys []Item) *Tree {
+	nodes := make([]*treeNode, len(keys))
+	keyBytes := 0
+	for i, key := range keys {
+		nodes[i] = newNode(key, nil)
+		keyBytes += keySize(key)
+	}
+	return &Tree{root: buildBalanced(nodes, nil), size: len(keys), keyBytes: keyBytes}
+}
+
+// Filter returns a new AVL tree that holds the keys of t that satisfy pred,
+// in O(n) time. t is not modified.
+func Filter(t *Tree, pred func(Item) bool) *Tree {
+	keys := make([]Item, 0, t.size)
+	t.AscendRange(nil, nil, func(key Item) bool {
+		if pred(key) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	return fromSorted(keys)
+}
+
+// Map returns a new AVL tree that holds the results of applying fn to each key
+// of t, ordered by cmp: its keys are the results wrapped in ByFuncs that use
+// cmp. If cmp is nil, the results must be Items themselves, and they are
+// ordered by their own Less methods. If fn turns out to preserve the order of
+// the keys, the new tree is built in O(n) time; otherwise, the results are
+// sorted first. If fn maps different keys to equal results, only the first of
+// them is kept and the returned error value is non-nil, much like inserting
+// them one by one would do. t is not modified.
+func Map(t *Tree, fn func(Item) interface{}, cmp Comparator) (*Tree, error) {
+	keys := make([]Item, 0, t.size)
+	t.AscendRange(nil, nil, func(key Item) bool {
+		if cmp != nil {
+			keys = append(keys, ByFunc{Value: fn(key), Compare: cmp})
+		} else {
+			keys = append(keys, fn(key).(Item))
+		}
+		return true
+	})
+	if strictlyAscending(keys) == -1 {
+		return fromSorted(keys), nil
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].Less(keys[j]) })
+	var err error
+	unique := keys[:0]
+	for _, key := range keys {
+		if len(unique) > 0 && key.Equal(unique[len(unique)-1]) {
+			if err == nil {
+				err = fmt.Errorf("Key already in the tree: %v", key)
+			}
+			continue
+		}
+		unique = append(unique, key)
+	}
+	return fromSorted(unique), err
+}
+
+// MapMonotonic returns a new AVL tree that holds the results of applying fn
+// to each key of t. fn must be strictly monotonic, i.e. it must preserve the
+// order of the keys, so that the new tree can be built in O(n) time without
+// sorting them. If it is not, the returned error value is non-nil and the
+// tree is nil. t is not modified.
+func MapMonotonic(t *Tree, fn func(Item) Item) (*Tree, error) {
+	keys := mapKeys(t, fn)
+	if i := strictlyAscending(keys); i != -1 {
+		return nil, fmt.Errorf("Mapping is not monotonic: %v is not less than %v", keys[i-1], keys[i])
+	}
+	return fromSorted(keys), nil
+}
+
+// mapKeys returns the results of applying fn to each key of t, in order.
+func mapKeys(t *Tree, fn func(Item) Item) []Item {
+	keys := make([]Item, 0, t.size)
+	t.AscendRange(nil, nil, func(key Item) bool {
+		keys = append(keys, fn(key))
+		return true
+	})
+	return keys
+}
+
+// strictlyAscending returns -1 if keys are sorted in strictly ascending order,
+// or else the index of the first key that is not greater than its previous.
+func strictlyAscending(keys []Item) int {
+	for i := 1; i < len(keys); i++ {
+		if !keys[i-1].Less(keys[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Fold calls fn for each key of t in ascending order, passing it the result of
+// its previous call (or init, for the first one), and returns the result of
+// its last call (or init, if t is empty). Unlike iterating over InOrder, it
+// does not allocate a slice of all keys.
+func Fold(t *Tree, init interface{}, fn func(acc interface{}, key Item) interface{}) interface{} {
+	acc := init
+	t.AscendRange(nil, nil, func(key Item) bool {
+		acc = fn(acc, key)
+		return true
+	})
+	return acc
+}
+
+// Reduce is like Fold, but uses the minimum key of t as the initial value,
+// calling fn for the rest of the keys. It returns an error value, which is
+// non-nil if t is empty.
+func Reduce(t *Tree, fn func(acc, key Item) Item) (Item, error) {
+	if t.root == nil {
+		return nil, fmt.Errorf("Empty tree")
+	}
+	var acc Item
+	first := true
+	t.AscendRange(nil, nil, func(key Item) bool {
+		if first {
+			acc, first = key, false
+		} else {
+			acc = fn(acc, key)
+		}
+		return true
+	})
+	return acc, nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newIntegerTree(t *testing.T, keys ...int) *Tree {
+	t.Helper()
+	tree := NewTree()
+	for _, key := range keys {
+		if err := tree.Insert(Integer(key)); err != nil {
+			t.Fatalf("\t%v\n", err)
+		}
+	}
+	return tree
+}
+
+func TestFilter(t *testing.T) {
+	tree := newIntegerTree(t, 9, 5, 10, 0, 6, 11, -1, 1, 2)
+	filtered := Filter(tree, func(key Item) bool { return key.(Integer)%2 == 0 })
+	verifyTree(t, filtered, []int{0, 2, 6, 10})
+	verifyTree(t, tree, []int{9, 5, 10, 0, 6, 11, -1, 1, 2})
+}
+
+func TestMap(t *testing.T) {
+	tree := newIntegerTree(t, 3, 1, 2, -2)
+
+	// Monotonic mappings are detected.
+	doubled, err := Map(tree, func(key Item) interface{} { return key.(Integer) * 2 }, nil)
+	if err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	verifyTree(t, doubled, []int{6, 2, 4, -4})
+
+	// Non-monotonic mappings are sorted, and duplicates reported.
+	squared, err := Map(tree, func(key Item) interface{} { return key.(Integer) * key.(Integer) }, nil)
+	if err == nil {
+		t.Errorf("\tExpected an error for mapping both 2 and -2 to 4!\n")
+	} else {
+		t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+	}
+	verifyTree(t, squared, []int{1, 4, 9})
+
+	negated, err := Map(tree, func(key Item) interface{} { return -key.(Integer) }, nil)
+	if err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	verifyTree(t, negated, []int{-3, -1, -2, 2})
+
+	verifyTree(t, tree, []int{3, 1, 2, -2})
+}
+
+func TestMapComparator(t *testing.T) {
+	tree := newIntegerTree(t, 9, 10, 100, 2, 25)
+	byString := func(a, b interface{}) int { return strings.Compare(a.(string), b.(string)) }
+
+	// The decimal strings of the keys sort differently than the keys.
+	strs, err := Map(tree, func(key Item) interface{} { return fmt.Sprint(key) }, byString)
+	if err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	verifyAVL(t, strs.root)
+	var got []string
+	strs.AscendRange(nil, nil, func(key Item) bool {
+		got = append(got, key.(ByFunc).Value.(string))
+		return true
+	})
+	if expected := []string{"10", "100", "2", "25", "9"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Map(fmt.Sprint) = %v; expected %v\n", got, expected)
+	}
+	if !strs.Contains(ByFunc{Value: "25", Compare: byString}) {
+		t.Errorf("\tMapped tree does not contain \"25\"\n")
+	}
+	if err := strs.Insert(ByFunc{Value: "11", Compare: byString}); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if max, _ := strs.Max(); max.(ByFunc).Value != "9" {
+		t.Errorf("strs.Max() = %v; expected 9\n", max)
+	}
+
+	// Results that cmp deems equal are reported.
+	firstDigits, err := Map(tree, func(key Item) interface{} { return fmt.Sprint(key)[:1] }, byString)
+	if err == nil {
+		t.Errorf("\tExpected an error for mapping 10 and 100 to 1!\n")
+	} else {
+		t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+	}
+	if firstDigits.Size() != 3 {
+		t.Errorf("firstDigits.Size() = %d; expected 3\n", firstDigits.Size())
+	}
+}
+
+func TestMapMonotonic(t *testing.T) {
+	tree := newIntegerTree(t, 3, 1, 2, -2)
+	shifted, err := MapMonotonic(tree, func(key Item) Item { return key.(Integer) + 10 })
+	if err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	verifyTree(t, shifted, []int{13, 11, 12, 8})
+
+	if _, err := MapMonotonic(tree, func(key Item) Item { return -key.(Integer) }); err == nil {
+		t.Errorf("\tExpected an error for a non-monotonic mapping!\n")
+	} else {
+		t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+	}
+}
+
+func TestFoldReduce(t *testing.T) {
+	tree := newIntegerTree(t, 3, 1, 2, 4)
+
+	sum := Fold(tree, 0, func(acc interface{}, key Item) interface{} {
+		return acc.(int) + int(key.(Integer))
+	})
+	if sum != 10 {
+		t.Errorf("Fold(sum) = %v; expected 10\n", sum)
+	}
+	digits := Fold(tree, "", func(acc interface{}, key Item) interface{} {
+		return acc.(string) + string(rune('0'+key.(Integer)))
+	})
+	if digits != "1234" {
+		t.Errorf("Fold(concat) = %v; expected 1234 (in-order)\n", digits)
+	}
+
+	diff, err := Reduce(tree, func(acc, key Item) Item { return acc.(Integer) - key.(Integer) })
+	if err != nil || diff != Integer(1-2-3-4) {
+		t.Errorf("Reduce(sub) = %v, %v; expected %d\n", diff, err, 1-2-3-4)
+	}
+	if _, err := Reduce(NewTree(), nil); err == nil {
+		t.Errorf("\tExpected an error for an empty tree!\n")
+	}
+}
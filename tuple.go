@@ -0,0 +1,168 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+// Lowest and Highest are sentinel Items, which compare less and greater,
+// respectively, than any other Item when used as components of a Tuple, or as
+// range bounds against Tuples. They allow open-ended bounds, e.g. the range of
+// all keys of tenant t, whatever their timestamp and id, is:
+//
+//	[Tuple{t, Lowest}, Tuple{t, Highest})
+var (
+	Lowest  Item = lowest{}
+	Highest Item = highest{}
+)
+
+// lowest is the type of Lowest.
+type lowest struct{}
+
+func (lowest) Equal(to Item) bool {
+	_, ok := to.(lowest)
+	return ok
+}
+
+func (lowest) Less(than Item) bool {
+	_, ok := than.(lowest)
+	return !ok
+}
+
+// highest is the type of Highest.
+type highest struct{}
+
+func (highest) Equal(to Item) bool {
+	_, ok := to.(highest)
+	return ok
+}
+
+func (highest) Less(than Item) bool {
+	return false
+}
+
+// Tuple is an Item composed of other Items, e.g. Tuple{String(tenant),
+// Time{timestamp}, Int(id)}. Tuples are ordered lexicographically: the first
+// components that differ determine the order of two Tuples, while a Tuple
+// that is a prefix of another one is less than it.
+//
+// Components at the same position in different Tuples must be of the same
+// type, except for Lowest and Highest.
+type Tuple []Item
+
+// Equal implements Item.
+func (t Tuple) Equal(to Item) bool {
+	return compareItems(t, to) == 0
+}
+
+// Less implements Item. It also supports Lowest and Highest as than.
+func (t Tuple) Less(than Item) bool {
+	return compareItems(t, than) < 0
+}
+
+// ByteSize implements Sizer, accounting for the components of t as well.
+func (t Tuple) ByteSize() int {
+	size := cap(t) * itemSize
+	for _, key := range t {
+		size += keySize(key)
+	}
+	return size
+}
+
+// compareItems returns -1, 0 or +1 if a is less than, equal to or greater
+// than b, respectively, taking Lowest, Highest and Tuples into account.
+func compareItems(a, b Item) int {
+	switch {
+	case a == Lowest:
+		if b == Lowest {
+			return 0
+		}
+		return -1
+	case a == Highest:
+		if b == Highest {
+			return 0
+		}
+		return 1
+	case b == Lowest:
+		return 1
+	case b == Highest:
+		return -1
+	}
+	if ta, ok := a.(Tuple); ok {
+		if tb, ok := b.(Tuple); ok {
+			return compareTuples(ta, tb)
+		}
+	}
+	if a.Less(b) {
+		return -1
+	}
+	if a.Equal(b) {
+		return 0
+	}
+	return 1
+}
+
+// compareTuples returns -1, 0 or +1 if a is less than, equal to or greater
+// than b, respectively.
+func compareTuples(a, b Tuple) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareItems(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+// comparePrefix compares the leading components of key to prefix, returning
+// -1, 0 or +1 if they are less than, equal to or greater than prefix. A key
+// shorter than prefix, whose components all match, is less than it.
+func comparePrefix(key, prefix Tuple) int {
+	for i := range prefix {
+		if i == len(key) {
+			return -1
+		}
+		if c := compareItems(key[i], prefix[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// subtreeScanPrefix calls visit, in ascending order, for each Tuple in the AVL
+// subtree rooted with n whose leading components are equal to prefix. Since
+// all such Tuples are contiguous in the tree, subtrees that lie entirely
+// before or after them are pruned. It returns false if visit stopped the scan.
+func (n *treeNode) subtreeScanPrefix(prefix Tuple, visit func(Item) bool) bool {
+	if n == nil {
+		return true
+	}
+	c := comparePrefix(n.key.(Tuple), prefix)
+	if c >= 0 {
+		if !n.left.subtreeScanPrefix(prefix, visit) {
+			return false
+		}
+		if c > 0 {
+			return true
+		}
+		if !visit(n.key) {
+			return false
+		}
+	}
+	return n.right.subtreeScanPrefix(prefix, visit)
+}
+
+// ScanPrefix calls visit, in ascending order, for each key in the AVL tree
+// whose leading components are equal to prefix, until visit returns false. An
+// empty prefix matches all keys. All keys in the tree must be Tuples.
+func (t *Tree) ScanPrefix(prefix Tuple, visit func(Item) bool) {
+	t.root.subtreeScanPrefix(prefix, visit)
+}
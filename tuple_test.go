@@ -0,0 +1,122 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ckatsak/goavl"
+	"github.com/ckatsak/goavl/avltest"
+)
+
+func key(tenant string, ts, id int) goavl.Tuple {
+	return goavl.Tuple{goavl.String(tenant), goavl.Int(ts), goavl.Int(id)}
+}
+
+func TestTupleOrdering(t *testing.T) {
+	avltest.CheckOrdering(t, []goavl.Item{
+		goavl.Tuple{},
+		goavl.Tuple{goavl.String("a")},
+		goavl.Tuple{goavl.String("a"), goavl.Lowest},
+		goavl.Tuple{goavl.String("a"), goavl.Int(1)},
+		goavl.Tuple{goavl.String("a"), goavl.Int(1), goavl.Int(0)},
+		goavl.Tuple{goavl.String("a"), goavl.Int(2)},
+		goavl.Tuple{goavl.String("a"), goavl.Highest},
+		goavl.Tuple{goavl.String("b")},
+		goavl.Tuple{goavl.String("b")},
+		goavl.Tuple{goavl.Highest},
+		goavl.Lowest,
+		goavl.Highest,
+	})
+}
+
+func newTupleTree(t *testing.T) *goavl.Tree {
+	t.Helper()
+	tree := goavl.NewTree()
+	for _, tenant := range []string{"acme", "globex", "initech"} {
+		for ts := 0; ts < 10; ts++ {
+			for id := 0; id < 3; id++ {
+				if err := tree.Insert(key(tenant, ts, id)); err != nil {
+					t.Fatalf("\t%v\n", err)
+				}
+			}
+		}
+	}
+	return tree
+}
+
+func collect(scan func(func(goavl.Item) bool)) []string {
+	var keys []string
+	scan(func(item goavl.Item) bool {
+		keys = append(keys, fmt.Sprint(item))
+		return true
+	})
+	return keys
+}
+
+func TestScanPrefix(t *testing.T) {
+	tree := newTupleTree(t)
+
+	for _, tc := range []struct {
+		prefix      goavl.Tuple
+		count       int
+		first, last string
+	}{
+		{goavl.Tuple{}, 90, "[acme 0 0]", "[initech 9 2]"},
+		{goavl.Tuple{goavl.String("globex")}, 30, "[globex 0 0]", "[globex 9 2]"},
+		{goavl.Tuple{goavl.String("globex"), goavl.Int(5)}, 3, "[globex 5 0]", "[globex 5 2]"},
+		{key("initech", 9, 2), 1, "[initech 9 2]", "[initech 9 2]"},
+		{goavl.Tuple{goavl.String("hooli")}, 0, "", ""},
+		{append(key("acme", 1, 1), goavl.Int(0)), 0, "", ""},
+	} {
+		keys := collect(func(visit func(goavl.Item) bool) { tree.ScanPrefix(tc.prefix, visit) })
+		if len(keys) != tc.count {
+			t.Errorf("ScanPrefix(%v) visited %d keys; expected %d\n", tc.prefix, len(keys), tc.count)
+			continue
+		}
+		if tc.count > 0 && (keys[0] != tc.first || keys[len(keys)-1] != tc.last) {
+			t.Errorf("ScanPrefix(%v) visited %v to %v; expected %v to %v\n",
+				tc.prefix, keys[0], keys[len(keys)-1], tc.first, tc.last)
+		}
+	}
+
+	// Stop early.
+	keys := collect(func(visit func(goavl.Item) bool) {
+		tree.ScanPrefix(goavl.Tuple{goavl.String("acme")}, func(item goavl.Item) bool {
+			return visit(item) && item.(goavl.Tuple)[1] != goavl.Int(1)
+		})
+	})
+	if len(keys) != 4 {
+		t.Errorf("ScanPrefix visited %v; expected to stop after 4 keys\n", keys)
+	}
+}
+
+func TestTupleSentinelBounds(t *testing.T) {
+	tree := newTupleTree(t)
+
+	// All keys of globex with a timestamp in [3, 5).
+	keys := collect(func(visit func(goavl.Item) bool) {
+		tree.AscendRange(
+			goavl.Tuple{goavl.String("globex"), goavl.Int(3), goavl.Lowest},
+			goavl.Tuple{goavl.String("globex"), goavl.Int(4), goavl.Highest},
+			visit)
+	})
+	if len(keys) != 6 || keys[0] != "[globex 3 0]" || keys[5] != "[globex 4 2]" {
+		t.Errorf("AscendRange visited %v; expected globex 3 0 to globex 4 2\n", keys)
+	}
+
+	// All keys of initech and beyond.
+	keys = collect(func(visit func(goavl.Item) bool) {
+		tree.AscendRange(goavl.Tuple{goavl.String("initech"), goavl.Lowest}, goavl.Highest, visit)
+	})
+	if len(keys) != 30 {
+		t.Errorf("AscendRange visited %d keys; expected 30\n", len(keys))
+	}
+}
@@ -0,0 +1,310 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import "fmt"
+
+// cow performs copy-on-write modifications of AVL subtrees, which may share
+// treeNodes with other trees: each treeNode is copied before it is modified,
+// unless it is owned, i.e. it was created by the same cow, and then the
+// copy is owned. Thus, the treeNodes on the paths to the modified ones are
+// copied once, and all other treeNodes are shared.
+type cow struct {
+	owned map[*treeNode]bool
+	c     *counters
+}
+
+func newCow(c *counters) *cow {
+	return &cow{owned: make(map[*treeNode]bool), c: c}
+}
+
+// own returns n if it is owned, or an owned copy of it otherwise.
+func (w *cow) own(n *treeNode) *treeNode {
+	if w.owned[n] {
+		return n
+	}
+	m := *n
+	w.owned[&m] = true
+	return &m
+}
+
+// newNode returns an owned treeNode for key.
+func (w *cow) newNode(key Item) *treeNode {
+	n := newNode(key, w.c.aug)
+	w.owned[n] = true
+	return n
+}
+
+// rebalance rebalances the owned treeNode n, as treeNode.rebalance, after
+// taking ownership of the children that the rotations modify.
+func (w *cow) rebalance(n *treeNode, kind rotationKind) *treeNode {
+	n.update(w.c.aug)
+	switch bal := n.balanceFactor(); {
+	case bal > 1:
+		n.left = w.own(n.left)
+		if n.left.balanceFactor() < 0 {
+			n.left.right = w.own(n.left.right)
+		}
+	case bal < -1:
+		n.right = w.own(n.right)
+		if n.right.balanceFactor() > 0 {
+			n.right.left = w.own(n.right.left)
+		}
+	}
+	return n.rebalance(w.c, kind)
+}
+
+// insert inserts key into the AVL subtree rooted with n, and returns its new
+// root.
+func (w *cow) insert(n *treeNode, key Item) (*treeNode, error) {
+	if n == nil {
+		return w.newNode(key), nil
+	}
+	var err error
+	switch {
+	case w.c.less(key, n.key):
+		var left *treeNode
+		if left, err = w.insert(n.left, key); err == nil {
+			n = w.own(n)
+			n.left = left
+		}
+	case w.c.equal(key, n.key):
+		return n, fmt.Errorf("Key already in the tree: %v", key)
+	default:
+		var right *treeNode
+		if right, err = w.insert(n.right, key); err == nil {
+			n = w.own(n)
+			n.right = right
+		}
+	}
+	if err != nil {
+		return n, err
+	}
+	return w.rebalance(n, insertRotation), nil
+}
+
+// delete deletes key from the AVL subtree rooted with n, and returns its new
+// root along with the deleted key.
+func (w *cow) delete(n *treeNode, key Item) (*treeNode, Item, error) {
+	if n == nil {
+		return nil, nil, fmt.Errorf("Key not found in the tree: %v", key)
+	}
+	var deleted Item
+	var err error
+	switch {
+	case w.c.less(key, n.key):
+		var left *treeNode
+		if left, deleted, err = w.delete(n.left, key); err == nil {
+			n = w.own(n)
+			n.left = left
+		}
+	case w.c.equal(key, n.key):
+		if n.left == nil {
+			return n.right, n.key, nil
+		}
+		if n.right == nil {
+			return n.left, n.key, nil
+		}
+		right, min := w.deleteMin(n.right)
+		deleted = n.key
+		n = w.own(n)
+		n.key, n.value, n.right = min.key, min.value, right
+	default:
+		var right *treeNode
+		if right, deleted, err = w.delete(n.right, key); err == nil {
+			n = w.own(n)
+			n.right = right
+		}
+	}
+	if err != nil {
+		return n, nil, err
+	}
+	return w.rebalance(n, deleteRotation), deleted, nil
+}
+
+// deleteMin deletes the minimum key from the non-empty AVL subtree rooted
+// with n, and returns its new root along with the treeNode of the minimum key,
+// which must not be modified.
+func (w *cow) deleteMin(n *treeNode) (*treeNode, *treeNode) {
+	if n.left == nil {
+		return n.right, n
+	}
+	left, min := w.deleteMin(n.left)
+	n = w.own(n)
+	n.left = left
+	return w.rebalance(n, deleteRotation), min
+}
+
+// Txn is a transaction on an AVL tree, which groups multiple Inserts and
+// Deletes so that they are applied to the tree all together, by Commit, or not
+// at all, by Rollback. Reads through the Txn see its own modifications, while
+// the tree remains unmodified until Commit.
+//
+// The Txn copies the treeNodes that its modifications affect, along with
+// their ancestors, and shares the rest with the tree; hence, Rollback is
+// free, and Commit merely replaces the root of the tree. For the same
+// reason, the tree must not be modified by other means while a Txn is in
+// progress, including by committing another Txn on it: the tree counts its
+// modifications, and if it has been modified since Begin, Insert, Delete,
+// Search, Min, Max and Commit fail, while the rest of the reads through the
+// Txn give undefined results. Such a Txn can only be rolled back.
+//
+// Insert respects the memory limit of the tree (see SetMemoryLimit), counting
+// the keys of the Txn.
+//
+// The comparisons and rotations that the Txn performs are added to the
+// statistics of the tree on Commit, and discarded on Rollback.
+type Txn struct {
+	tree     *Tree
+	root     *treeNode
+	size     int
+	keyBytes int
+	stats    counters
+	w        *cow
+	mods     uint64 // the modifications of tree at Begin
+}
+
+// Begin starts a new transaction on the AVL tree.
+func (t *Tree) Begin() *Txn {
+	txn := &Txn{tree: t, root: t.root, size: t.size, keyBytes: t.keyBytes, mods: t.mods}
+	txn.stats.aug = t.stats.aug
+	txn.w = newCow(&txn.stats)
+	return txn
+}
+
+var errTxnDone = fmt.Errorf("Transaction already committed or rolled back")
+
+var errTxnConflict = fmt.Errorf("Tree modified since the transaction began")
+
+// check returns a non-nil error value if the Txn is done, or if its AVL tree
+// has been modified since the Txn began.
+func (txn *Txn) check() error {
+	if txn.w == nil {
+		return errTxnDone
+	}
+	if txn.mods != txn.tree.mods {
+		return errTxnConflict
+	}
+	return nil
+}
+
+// Insert inserts a key in the Txn and returns an error value, which is non-nil
+// if the key already exists in it, if inserting it would exceed the memory
+// limit of the tree, or if the Txn is done or conflicts with the tree.
+func (txn *Txn) Insert(key Item) error {
+	if err := txn.check(); err != nil {
+		return err
+	}
+	if err := checkMemoryLimit(txn.root, txn.size, txn.keyBytes, txn.tree.memLimit, key); err != nil {
+		return err
+	}
+	root, err := txn.w.insert(txn.root, key)
+	if err == nil {
+		txn.root = root
+		txn.size++
+		txn.keyBytes += keySize(key)
+	}
+	return err
+}
+
+// Delete removes a key from the Txn and returns an error value, which is
+// non-nil if the key doesn't exist in it, or if the Txn is done or conflicts
+// with the tree.
+func (txn *Txn) Delete(key Item) error {
+	if err := txn.check(); err != nil {
+		return err
+	}
+	root, deleted, err := txn.w.delete(txn.root, key)
+	if err == nil {
+		txn.root = root
+		txn.size--
+		txn.keyBytes -= keySize(deleted)
+	}
+	return err
+}
+
+// Size returns the current number of keys in the Txn.
+func (txn *Txn) Size() int {
+	return txn.size
+}
+
+// Search looks up key in the Txn; see Tree.Search.
+func (txn *Txn) Search(key Item) (Item, error) {
+	if err := txn.check(); err != nil {
+		return nil, err
+	}
+	n := txn.root.subtreeSearch(key)
+	if n == nil {
+		return nil, fmt.Errorf("Key not found in the tree: %v", key)
+	}
+	return n.key, nil
+}
+
+// Contains reports whether key exists in the Txn.
+func (txn *Txn) Contains(key Item) bool {
+	return txn.root.subtreeSearch(key) != nil
+}
+
+// Min returns the minimum key in the Txn; see Tree.Min.
+func (txn *Txn) Min() (Item, error) {
+	if err := txn.check(); err != nil {
+		return nil, err
+	}
+	if txn.root == nil {
+		return nil, fmt.Errorf("Empty tree")
+	}
+	return txn.root.subtreeMin().key, nil
+}
+
+// Max returns the maximum key in the Txn; see Tree.Max.
+func (txn *Txn) Max() (Item, error) {
+	if err := txn.check(); err != nil {
+		return nil, err
+	}
+	if txn.root == nil {
+		return nil, fmt.Errorf("Empty tree")
+	}
+	return txn.root.subtreeMax().key, nil
+}
+
+// InOrder returns a slice of all keys in the Txn, in ascending order.
+func (txn *Txn) InOrder() []Item {
+	return txn.root.subtreeInOrder()
+}
+
+// AscendRange calls visit for each key in the Txn in [lo, hi); see
+// Tree.AscendRange.
+func (txn *Txn) AscendRange(lo, hi Item, visit func(Item) bool) {
+	txn.root.subtreeAscendRange(lo, hi, visit)
+}
+
+// Commit applies the modifications of the Txn to the AVL tree. It returns an
+// error value, which is non-nil if the Txn is already done, or if the tree has
+// been modified since the Txn began, in which case the Txn remains in progress
+// and may only be rolled back.
+func (txn *Txn) Commit() error {
+	if err := txn.check(); err != nil {
+		return err
+	}
+	txn.tree.root, txn.tree.size, txn.tree.keyBytes = txn.root, txn.size, txn.keyBytes
+	txn.tree.mods++
+	txn.tree.stats.add(&txn.stats)
+	txn.w = nil
+	return nil
+}
+
+// Rollback discards the modifications of the Txn. It returns an error value,
+// which is non-nil if the Txn is already done.
+func (txn *Txn) Rollback() error {
+	if txn.w == nil {
+		return errTxnDone
+	}
+	txn.root, txn.w = nil, nil
+	return nil
+}
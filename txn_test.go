@@ -0,0 +1,246 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestTxn(t *testing.T) {
+	for iter := 0; iter < 50; iter++ {
+		tree := NewTree()
+		model := map[int]bool{}
+		for _, r := range rand.Perm(300) {
+			if r%2 == 0 {
+				tree.Insert(Integer(r))
+				model[r] = true
+			}
+		}
+		before := fmt.Sprint(tree.PreOrder())
+
+		txn := tree.Begin()
+		txnModel := map[int]bool{}
+		for k, v := range model {
+			txnModel[k] = v
+		}
+		for i := 0; i < 200; i++ {
+			key := rand.Intn(300)
+			if rand.Intn(2) == 0 {
+				if err := txn.Insert(Integer(key)); (err == nil) == txnModel[key] {
+					t.Fatalf("txn.Insert(%d) = %v\n", key, err)
+				}
+				txnModel[key] = true
+			} else {
+				if err := txn.Delete(Integer(key)); (err == nil) != txnModel[key] {
+					t.Fatalf("txn.Delete(%d) = %v\n", key, err)
+				}
+				txnModel[key] = false
+			}
+			if txn.Contains(Integer(key)) != txnModel[key] {
+				t.Fatalf("the txn does not see its own write of %d\n", key)
+			}
+		}
+
+		// The tree is not modified until the txn is committed.
+		if after := fmt.Sprint(tree.PreOrder()); after != before {
+			t.Fatalf("tree modified by an uncommitted txn\n")
+		}
+		verifyTree(t, tree, keysOf(model))
+		verifyTree(t, &Tree{root: txn.root, size: txn.Size()}, keysOf(txnModel))
+
+		if iter%2 == 0 {
+			if err := txn.Rollback(); err != nil {
+				t.Fatalf("\t%v\n", err)
+			}
+			verifyTree(t, tree, keysOf(model))
+		} else {
+			if err := txn.Commit(); err != nil {
+				t.Fatalf("\t%v\n", err)
+			}
+			verifyTree(t, tree, keysOf(txnModel))
+		}
+		if err := txn.Insert(Integer(-1)); err == nil {
+			t.Errorf("\tExpected an error for using a finished txn!\n")
+		}
+		if err := txn.Commit(); err == nil {
+			t.Errorf("\tExpected an error for committing a finished txn!\n")
+		}
+	}
+}
+
+// keysOf returns the keys of model that are present.
+func keysOf(model map[int]bool) []int {
+	keys := []int{}
+	for key, present := range model {
+		if present {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func TestTxnReads(t *testing.T) {
+	tree := newIntegerTree(t, 2, 4, 6)
+	txn := tree.Begin()
+	txn.Insert(Integer(1))
+	txn.Insert(Integer(7))
+	txn.Delete(Integer(4))
+	if min, err := txn.Min(); err != nil || min != Integer(1) {
+		t.Errorf("txn.Min() = %v, %v; expected 1\n", min, err)
+	}
+	if max, err := txn.Max(); err != nil || max != Integer(7) {
+		t.Errorf("txn.Max() = %v, %v; expected 7\n", max, err)
+	}
+	if _, err := txn.Search(Integer(4)); err == nil {
+		t.Errorf("\tExpected an error for searching a deleted key!\n")
+	}
+	verifyItems(t, txn.InOrder(), 1, 2, 6, 7)
+	verifyItems(t, tree.InOrder(), 2, 4, 6)
+
+	// Keys already in the tree are still rejected, leaving the txn intact.
+	if err := txn.Insert(Integer(2)); err == nil {
+		t.Errorf("\tExpected an error for inserting a duplicate key!\n")
+	} else {
+		t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+	}
+	if err := txn.Commit(); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	verifyItems(t, tree.InOrder(), 1, 2, 6, 7)
+	if err := tree.Insert(Integer(3)); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	verifyTree(t, tree, []int{1, 2, 3, 6, 7})
+}
+
+func TestTxnStats(t *testing.T) {
+	tree := newIntegerTree(t, 1, 2)
+	before := tree.Stats()
+
+	// Ascending insertions in a rolled back txn leave the stats untouched.
+	txn := tree.Begin()
+	for i := 3; i <= 8; i++ {
+		txn.Insert(Integer(i))
+	}
+	if err := txn.Rollback(); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if s := tree.Stats(); s.Comparisons != before.Comparisons ||
+		s.InsertRotations != before.InsertRotations {
+		t.Errorf("Stats() after Rollback = %+v; expected %+v\n", s, before)
+	}
+
+	// Once committed, they are reported as insert rotations only.
+	txn = tree.Begin()
+	for i := 3; i <= 8; i++ {
+		txn.Insert(Integer(i))
+	}
+	if err := txn.Commit(); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	s := tree.Stats()
+	if s.Comparisons <= before.Comparisons {
+		t.Errorf("\tExpected the comparisons of the txn to be counted!\n")
+	}
+	if s.InsertRotations == before.InsertRotations {
+		t.Errorf("\tExpected the rotations of the txn to be counted!\n")
+	}
+	if s.DeleteRotations != before.DeleteRotations ||
+		s.DeleteDoubleRotations != before.DeleteDoubleRotations {
+		t.Errorf("delete rotations = %d, %d; expected %d, %d\n",
+			s.DeleteRotations, s.DeleteDoubleRotations,
+			before.DeleteRotations, before.DeleteDoubleRotations)
+	}
+}
+
+func TestTxnConflict(t *testing.T) {
+	for name, modify := range map[string]func(tree *Tree){
+		"Insert":      func(tree *Tree) { tree.Insert(Integer(10)) },
+		"Delete":      func(tree *Tree) { tree.Delete(Integer(2)) },
+		"PopMin":      func(tree *Tree) { tree.PopMin() },
+		"DeleteRange": func(tree *Tree) { tree.DeleteRange(Integer(1), Integer(3)) },
+		"DeleteFunc":  func(tree *Tree) { tree.DeleteFunc(func(key Item) bool { return key == Integer(4) }) },
+		"ApplyBatch":  func(tree *Tree) { tree.ApplyBatch([]Op{{OpInsert, Integer(0)}}) },
+		"Commit": func(tree *Tree) {
+			other := tree.Begin()
+			other.Insert(Integer(5))
+			other.Commit()
+		},
+	} {
+		tree := newIntegerTree(t, 2, 4, 6)
+		txn := tree.Begin()
+		if err := txn.Insert(Integer(3)); err != nil {
+			t.Fatalf("%s: %v\n", name, err)
+		}
+		modify(tree)
+		if err := txn.Insert(Integer(7)); err == nil {
+			t.Errorf("%s: expected an error for inserting in a conflicting txn!\n", name)
+		}
+		if err := txn.Delete(Integer(3)); err == nil {
+			t.Errorf("%s: expected an error for deleting in a conflicting txn!\n", name)
+		}
+		if _, err := txn.Min(); err == nil {
+			t.Errorf("%s: expected an error for reading a conflicting txn!\n", name)
+		}
+		if err := txn.Commit(); err == nil {
+			t.Errorf("%s: expected an error for committing a conflicting txn!\n", name)
+		} else {
+			t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+		}
+		if tree.Contains(Integer(3)) {
+			t.Errorf("%s: conflicting txn committed\n", name)
+		}
+		if err := txn.Rollback(); err != nil {
+			t.Errorf("%s: %v\n", name, err)
+		}
+	}
+
+	// Failed operations do not count as modifications.
+	tree := newIntegerTree(t, 2, 4, 6)
+	txn := tree.Begin()
+	tree.Insert(Integer(2))
+	tree.Delete(Integer(3))
+	tree.DeleteFunc(func(Item) bool { return false })
+	if err := txn.Commit(); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+}
+
+func TestTxnMemoryLimit(t *testing.T) {
+	tree := newIntegerTree(t, 1, 2)
+	tree.SetMemoryLimit(tree.MemoryUsage() + 2*nodeSize)
+	txn := tree.Begin()
+	for _, key := range []Integer{3, 4} {
+		if err := txn.Insert(key); err != nil {
+			t.Errorf("\t%v\n", err)
+		}
+	}
+	err := txn.Insert(Integer(5))
+	if _, ok := err.(*MemoryLimitError); !ok {
+		t.Errorf("txn.Insert(5) = %v; expected a *MemoryLimitError\n", err)
+	}
+	// Deleting makes room again, and duplicates are reported as such.
+	if err := txn.Delete(Integer(1)); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if err := txn.Insert(Integer(5)); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if err := txn.Insert(Integer(5)); err == nil {
+		t.Errorf("\tExpected an error for inserting a duplicate key!\n")
+	} else if _, ok := err.(*MemoryLimitError); ok {
+		t.Errorf("txn.Insert(5) = %v; expected a duplicate key error\n", err)
+	}
+	if err := txn.Commit(); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	verifyTree(t, tree, []int{2, 3, 4, 5})
+}
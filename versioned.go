@@ -0,0 +1,240 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Snapshot is a read-only AVL tree, as it was at some version of a Versioned.
+type Snapshot struct {
+	root    *treeNode
+	size    int
+	version uint64
+}
+
+// Version returns the version of the Snapshot.
+func (s *Snapshot) Version() uint64 {
+	return s.version
+}
+
+// Size returns the number of keys in the Snapshot.
+func (s *Snapshot) Size() int {
+	return s.size
+}
+
+// Search looks up key in the Snapshot; see Tree.Search.
+func (s *Snapshot) Search(key Item) (Item, error) {
+	n := s.root.subtreeSearch(key)
+	if n == nil {
+		return nil, fmt.Errorf("Key not found in the tree: %v", key)
+	}
+	return n.key, nil
+}
+
+// Contains reports whether key exists in the Snapshot.
+func (s *Snapshot) Contains(key Item) bool {
+	return s.root.subtreeSearch(key) != nil
+}
+
+// Min returns the minimum key in the Snapshot; see Tree.Min.
+func (s *Snapshot) Min() (Item, error) {
+	if s.root == nil {
+		return nil, fmt.Errorf("Empty tree")
+	}
+	return s.root.subtreeMin().key, nil
+}
+
+// Max returns the maximum key in the Snapshot; see Tree.Max.
+func (s *Snapshot) Max() (Item, error) {
+	if s.root == nil {
+		return nil, fmt.Errorf("Empty tree")
+	}
+	return s.root.subtreeMax().key, nil
+}
+
+// Height returns the height of the Snapshot.
+func (s *Snapshot) Height() int {
+	return s.root.height()
+}
+
+// InOrder returns a slice of all keys in the Snapshot, in ascending order.
+func (s *Snapshot) InOrder() []Item {
+	return s.root.subtreeInOrder()
+}
+
+// AscendRange calls visit for each key in the Snapshot in [lo, hi); see
+// Tree.AscendRange.
+func (s *Snapshot) AscendRange(lo, hi Item, visit func(Item) bool) {
+	s.root.subtreeAscendRange(lo, hi, visit)
+}
+
+// Versioned is an AVL tree that keeps its history: each successful Insert or
+// Delete produces a new version, whose number is greater than all previous
+// ones. Mutations can be undone and redone, and past versions can be read
+// through Snapshots.
+//
+// Versions share all treeNodes that their mutations did not affect, so that
+// each version takes O(log n) additional space. The oldest versions can be
+// pruned, either automatically, by bounding the number of versions kept, or
+// explicitly, through Prune.
+type Versioned struct {
+	versions    []*Snapshot // retained, in ascending order of versions
+	cur         int         // index of the current version in versions
+	next        uint64      // number of the next version
+	maxVersions int
+	stats       counters
+}
+
+// NewVersioned creates a new empty Versioned AVL tree, at version 0, which
+// keeps at most maxVersions versions (including the current one and those
+// that can be redone), pruning the oldest ones as needed. If maxVersions is 0,
+// all versions are kept.
+func NewVersioned(maxVersions int) *Versioned {
+	return &Versioned{versions: []*Snapshot{{}}, next: 1, maxVersions: maxVersions}
+}
+
+// Version returns the number of the current version.
+func (v *Versioned) Version() uint64 {
+	return v.versions[v.cur].version
+}
+
+// Current returns a Snapshot of the current version.
+func (v *Versioned) Current() *Snapshot {
+	return v.versions[v.cur]
+}
+
+// Size returns the number of keys in the current version.
+func (v *Versioned) Size() int {
+	return v.Current().Size()
+}
+
+// Contains reports whether key exists in the current version.
+func (v *Versioned) Contains(key Item) bool {
+	return v.Current().Contains(key)
+}
+
+// InOrder returns a slice of all keys in the current version, in ascending
+// order.
+func (v *Versioned) InOrder() []Item {
+	return v.Current().InOrder()
+}
+
+// Stats returns the operational statistics of the Versioned AVL tree; see
+// Tree.Stats. The counters are cumulative over all versions produced, while
+// the shape-related fields describe the current version.
+func (v *Versioned) Stats() Stats {
+	cur := v.Current()
+	return newStats(cur.root, cur.size, &v.stats)
+}
+
+// push makes a new version out of root and size the current one, discarding
+// any versions that could be redone, and prunes the oldest ones if needed.
+func (v *Versioned) push(root *treeNode, size int) {
+	v.versions = append(v.versions[:v.cur+1], &Snapshot{root: root, size: size, version: v.next})
+	v.cur++
+	v.next++
+	if v.maxVersions > 0 && len(v.versions) > v.maxVersions {
+		v.drop(len(v.versions) - v.maxVersions)
+	}
+}
+
+// drop drops the n oldest versions.
+func (v *Versioned) drop(n int) {
+	clear(v.versions[:n])
+	v.versions = v.versions[n:]
+	v.cur -= n
+}
+
+// Insert inserts a key into the current version, producing a new one. It
+// returns an error value, which is non-nil if the key already exists, in which
+// case no new version is produced.
+func (v *Versioned) Insert(key Item) error {
+	cur := v.Current()
+	root, err := newCow(&v.stats).insert(cur.root, key)
+	if err == nil {
+		v.push(root, cur.size+1)
+	}
+	return err
+}
+
+// Delete removes a key from the current version, producing a new one. It
+// returns an error value, which is non-nil if the key doesn't exist, in which
+// case no new version is produced.
+func (v *Versioned) Delete(key Item) error {
+	cur := v.Current()
+	root, _, err := newCow(&v.stats).delete(cur.root, key)
+	if err == nil {
+		v.push(root, cur.size-1)
+	}
+	return err
+}
+
+// Undo makes the previous version the current one. It returns an error value,
+// which is non-nil if there is no previous version, e.g. because it was
+// pruned.
+func (v *Versioned) Undo() error {
+	if v.cur == 0 {
+		return fmt.Errorf("Nothing to undo")
+	}
+	v.cur--
+	return nil
+}
+
+// Redo makes the version that was last undone the current one again. It
+// returns an error value, which is non-nil if there is no such version, e.g.
+// because a new version was produced after the Undo.
+func (v *Versioned) Redo() error {
+	if v.cur == len(v.versions)-1 {
+		return fmt.Errorf("Nothing to redo")
+	}
+	v.cur++
+	return nil
+}
+
+// search returns the index of version in versions, or -1 if it is not
+// retained.
+func (v *Versioned) search(version uint64) int {
+	i := sort.Search(len(v.versions), func(i int) bool { return v.versions[i].version >= version })
+	if i < len(v.versions) && v.versions[i].version == version {
+		return i
+	}
+	return -1
+}
+
+// At returns a Snapshot of the given version, and an error value, which is
+// non-nil if the version is not retained, i.e. it was pruned, discarded, or
+// never existed.
+func (v *Versioned) At(version uint64) (*Snapshot, error) {
+	i := v.search(version)
+	if i < 0 {
+		return nil, fmt.Errorf("Version not found: %d", version)
+	}
+	return v.versions[i], nil
+}
+
+// Versions returns the numbers of the retained versions, in ascending order.
+func (v *Versioned) Versions() []uint64 {
+	ret := make([]uint64, len(v.versions))
+	for i, s := range v.versions {
+		ret[i] = s.version
+	}
+	return ret
+}
+
+// Prune drops all versions older than the given one, except for the current
+// version, and returns their number. Snapshots of them that were obtained
+// before remain valid.
+func (v *Versioned) Prune(version uint64) int {
+	n := sort.Search(len(v.versions), func(i int) bool { return v.versions[i].version >= version })
+	n = min(n, v.cur)
+	v.drop(n)
+	return n
+}
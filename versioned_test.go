@@ -0,0 +1,157 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// verifySnapshot checks that s is a valid AVL tree holding exactly keys.
+func verifySnapshot(t *testing.T, s *Snapshot, keys []int) {
+	t.Helper()
+	verifyTree(t, &Tree{root: s.root, size: s.Size()}, keys)
+}
+
+func TestVersioned(t *testing.T) {
+	v := NewVersioned(0)
+	history := [][]int{{}}
+	model := map[int]bool{}
+	for i := 0; i < 500; i++ {
+		key := rand.Intn(100)
+		var err error
+		if rand.Intn(3) == 0 {
+			err = v.Delete(Integer(key))
+			if (err == nil) != model[key] {
+				t.Fatalf("Delete(%d) = %v\n", key, err)
+			}
+			model[key] = false
+		} else {
+			err = v.Insert(Integer(key))
+			if (err == nil) == model[key] {
+				t.Fatalf("Insert(%d) = %v\n", key, err)
+			}
+			model[key] = true
+		}
+		if err == nil {
+			history = append(history, keysOf(model))
+		}
+		if v.Version() != uint64(len(history)-1) {
+			t.Fatalf("Version() = %d; expected %d\n", v.Version(), len(history)-1)
+		}
+	}
+
+	// All past versions are intact, despite sharing their treeNodes.
+	for version, keys := range history {
+		s, err := v.At(uint64(version))
+		if err != nil {
+			t.Fatalf("\t%v\n", err)
+		}
+		verifySnapshot(t, s, keys)
+	}
+
+	// Undo all the way back, and then redo.
+	for version := len(history) - 1; version > 0; version-- {
+		if err := v.Undo(); err != nil {
+			t.Fatalf("\t%v\n", err)
+		}
+	}
+	if err := v.Undo(); err == nil {
+		t.Errorf("\tExpected an error for undoing version 0!\n")
+	}
+	if v.Size() != 0 || v.Version() != 0 {
+		t.Errorf("version %d holds %d keys after undoing everything\n", v.Version(), v.Size())
+	}
+	for i := 0; i < 10; i++ {
+		v.Redo()
+	}
+	verifySnapshot(t, v.Current(), history[10])
+}
+
+func TestVersionedBranch(t *testing.T) {
+	v := NewVersioned(0)
+	v.Insert(Integer(1))
+	v.Insert(Integer(2))
+	v.Insert(Integer(3))
+	v.Undo()
+	v.Undo()
+	if err := v.Insert(Integer(4)); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	// Versions 2 and 3 are discarded; the new one gets the next number.
+	if err := v.Redo(); err == nil {
+		t.Errorf("\tExpected an error for redoing after a new version!\n")
+	} else {
+		t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+	}
+	if _, err := v.At(2); err == nil {
+		t.Errorf("\tExpected an error for a discarded version!\n")
+	}
+	if v.Version() != 4 {
+		t.Errorf("Version() = %d; expected 4\n", v.Version())
+	}
+	verifyItems(t, v.InOrder(), 1, 4)
+	if got := v.Versions(); len(got) != 3 || got[0] != 0 || got[1] != 1 || got[2] != 4 {
+		t.Errorf("Versions() = %v; expected [0 1 4]\n", got)
+	}
+}
+
+func TestVersionedPruning(t *testing.T) {
+	v := NewVersioned(5)
+	for i := 0; i < 20; i++ {
+		v.Insert(Integer(i))
+	}
+	versions := v.Versions()
+	if len(versions) != 5 || versions[0] != 16 {
+		t.Errorf("Versions() = %v; expected [16 ... 20]\n", versions)
+	}
+	if _, err := v.At(15); err == nil {
+		t.Errorf("\tExpected an error for a pruned version!\n")
+	}
+	old, _ := v.At(16)
+
+	v.Undo()
+	v.Undo()
+	if n := v.Prune(20); n != 2 {
+		t.Errorf("Prune(20) = %d; expected 2 (up to the current version)\n", n)
+	}
+	if err := v.Undo(); err == nil {
+		t.Errorf("\tExpected an error for undoing a pruned version!\n")
+	}
+	if err := v.Redo(); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+
+	// Snapshots outlive pruning.
+	keys := make([]int, 16)
+	for i := range keys {
+		keys[i] = i
+	}
+	verifySnapshot(t, old, keys)
+}
+
+func TestVersionedStats(t *testing.T) {
+	v := NewVersioned(0)
+	for i := 1; i <= 7; i++ {
+		v.Insert(Integer(i))
+	}
+	s := v.Stats()
+	if s.Comparisons == 0 || s.InsertRotations == 0 {
+		t.Errorf("\tExpected non-zero comparisons and insert rotations!\n")
+	}
+	if s.MaxPathLength != 3 || len(s.DepthHistogram) != 3 {
+		t.Errorf("Stats() = %+v; expected a perfect tree of height 3\n", s)
+	}
+
+	// Undo only affects the shape of the current version.
+	v.Undo()
+	if u := v.Stats(); u.Comparisons != s.Comparisons || u.DepthHistogram[2] != 3 {
+		t.Errorf("Stats() after Undo = %+v\n", u)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import "fmt"
+
+// View is a live view of the keys of an AVL tree that are greater than or
+// equal to its lower bound and less than its upper bound. A nil bound leaves
+// the View unbounded on that side. It holds no keys of its own: modifications
+// of the tree are visible through the View, and vice versa.
+type View struct {
+	tree   *Tree
+	lo, hi Item
+}
+
+// View returns a View of the keys of the AVL tree that are greater than or
+// equal to lo and less than hi.
+func (t *Tree) View(lo, hi Item) *View {
+	return &View{tree: t, lo: lo, hi: hi}
+}
+
+// Head returns a View of the keys of the AVL tree that are less than hi.
+func (t *Tree) Head(hi Item) *View {
+	return t.View(nil, hi)
+}
+
+// Tail returns a View of the keys of the AVL tree that are greater than or
+// equal to lo.
+func (t *Tree) Tail(lo Item) *View {
+	return t.View(lo, nil)
+}
+
+// View returns a View of the keys of v that are greater than or equal to lo
+// and less than hi, i.e. of the intersection of the two ranges.
+func (v *View) View(lo, hi Item) *View {
+	if lo == nil || (v.lo != nil && lo.Less(v.lo)) {
+		lo = v.lo
+	}
+	if hi == nil || (v.hi != nil && v.hi.Less(hi)) {
+		hi = v.hi
+	}
+	return v.tree.View(lo, hi)
+}
+
+// Bounds returns the lower and upper bounds of the View.
+func (v *View) Bounds() (lo, hi Item) {
+	return v.lo, v.hi
+}
+
+// InRange reports whether key lies within the bounds of the View, regardless
+// of whether it exists in the tree.
+func (v *View) InRange(key Item) bool {
+	return (v.lo == nil || !key.Less(v.lo)) && (v.hi == nil || key.Less(v.hi))
+}
+
+// Size returns the current number of keys in the View. Since the treeNodes do
+// not keep track of the sizes of their subtrees, it takes time linear in the
+// number of keys in the View.
+func (v *View) Size() int {
+	count := 0
+	v.Ascend(func(Item) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// Insert inserts a key into the underlying AVL tree and returns an error
+// value, which is non-nil if the key lies outside the View or already exists
+// in the tree.
+func (v *View) Insert(key Item) error {
+	if !v.InRange(key) {
+		return fmt.Errorf("Key out of the range of the view: %v", key)
+	}
+	return v.tree.Insert(key)
+}
+
+// Delete removes a key from the underlying AVL tree and returns an error
+// value, which is non-nil if the key lies outside the View or doesn't exist
+// in the tree.
+func (v *View) Delete(key Item) error {
+	if !v.InRange(key) {
+		return fmt.Errorf("Key out of the range of the view: %v", key)
+	}
+	return v.tree.Delete(key)
+}
+
+// Contains reports whether key exists in the View.
+func (v *View) Contains(key Item) bool {
+	return v.InRange(key) && v.tree.Contains(key)
+}
+
+// Min returns the minimum key in the View and an error value. If the View is
+// empty, the error value is non-nil and the result should not be trusted.
+func (v *View) Min() (Item, error) {
+	var n *treeNode
+	if v.lo == nil {
+		if v.tree.root != nil {
+			n = v.tree.root.subtreeMin()
+		}
+	} else {
+		n = v.tree.root.subtreeCeiling(v.lo)
+	}
+	if n == nil || !v.InRange(n.key) {
+		return nil, fmt.Errorf("Empty view")
+	}
+	return n.key, nil
+}
+
+// Max returns the maximum key in the View and an error value. If the View is
+// empty, the error value is non-nil and the result should not be trusted.
+func (v *View) Max() (Item, error) {
+	var n *treeNode
+	if v.hi == nil {
+		if v.tree.root != nil {
+			n = v.tree.root.subtreeMax()
+		}
+	} else {
+		n = v.tree.root.subtreeLower(v.hi)
+	}
+	if n == nil || !v.InRange(n.key) {
+		return nil, fmt.Errorf("Empty view")
+	}
+	return n.key, nil
+}
+
+// Ascend calls visit for each key in the View, in ascending order, until
+// visit returns false.
+func (v *View) Ascend(visit func(Item) bool) {
+	v.tree.AscendRange(v.lo, v.hi, visit)
+}
+
+// AscendRange calls visit for each key in the View that is greater than or
+// equal to lo and less than hi, in ascending order, until visit returns false.
+// A nil lo or hi leaves the range bounded only by the View on that side.
+func (v *View) AscendRange(lo, hi Item, visit func(Item) bool) {
+	v.View(lo, hi).Ascend(visit)
+}
+
+// InOrder returns a slice of all keys currently in the View, in ascending
+// order.
+func (v *View) InOrder() []Item {
+	ret := []Item{}
+	v.Ascend(func(key Item) bool {
+		ret = append(ret, key)
+		return true
+	})
+	return ret
+}
+
+// subtreeCeiling returns the treeNode associated with the least key in the
+// AVL subtree rooted with n that is greater than or equal to key, or nil if
+// there is no such treeNode.
+func (n *treeNode) subtreeCeiling(key Item) *treeNode {
+	var ret *treeNode
+	for curr := n; curr != nil; {
+		if curr.key.Less(key) {
+			curr = curr.right
+		} else {
+			ret, curr = curr, curr.left
+		}
+	}
+	return ret
+}
+
+// subtreeLower returns the treeNode associated with the greatest key in the
+// AVL subtree rooted with n that is less than key, or nil if there is no such
+// treeNode.
+func (n *treeNode) subtreeLower(key Item) *treeNode {
+	var ret *treeNode
+	for curr := n; curr != nil; {
+		if curr.key.Less(key) {
+			ret, curr = curr, curr.right
+		} else {
+			curr = curr.left
+		}
+	}
+	return ret
+}
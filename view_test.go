@@ -0,0 +1,123 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package goavl
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// verifyItems checks that items holds exactly the expected keys, in order.
+func verifyItems(t *testing.T, items []Item, expected ...int) {
+	t.Helper()
+	if len(items) != len(expected) {
+		t.Fatalf("got %d keys (%v); expected %d (%v)\n", len(items), items, len(expected), expected)
+	}
+	for i, key := range expected {
+		if items[i] != Integer(key) {
+			t.Fatalf("items[%d] is %v, should be %d\n", i, items[i], key)
+		}
+	}
+}
+
+func TestView(t *testing.T) {
+	tree := NewTree()
+	for _, r := range rand.Perm(100) {
+		tree.Insert(Integer(2 * r))
+	}
+
+	for iter := 0; iter < 500; iter++ {
+		lo, hi := rand.Intn(220)-10, rand.Intn(220)-10
+		var v *View
+		switch iter % 4 {
+		case 0:
+			v = tree.View(Integer(lo), Integer(hi))
+		case 1:
+			v, lo = tree.Head(Integer(hi)), -1<<31
+		case 2:
+			v, hi = tree.Tail(Integer(lo)), 1<<31
+		case 3:
+			v, lo, hi = tree.View(nil, nil), -1<<31, 1<<31
+		}
+
+		expected := []int{}
+		for k := 0; k < 200; k += 2 {
+			if lo <= k && k < hi {
+				expected = append(expected, k)
+			}
+		}
+		if size := v.Size(); size != len(expected) {
+			t.Fatalf("[%d, %d): Size() = %d; expected %d\n", lo, hi, size, len(expected))
+		}
+		verifyItems(t, v.InOrder(), expected...)
+
+		min, errMin := v.Min()
+		max, errMax := v.Max()
+		if len(expected) == 0 {
+			if errMin == nil || errMax == nil {
+				t.Fatalf("[%d, %d): expected errors for an empty view!\n", lo, hi)
+			}
+			continue
+		}
+		if errMin != nil || min != Integer(expected[0]) {
+			t.Fatalf("[%d, %d): Min() = %v, %v; expected %v\n", lo, hi, min, errMin, expected[0])
+		}
+		if errMax != nil || max != Integer(expected[len(expected)-1]) {
+			t.Fatalf("[%d, %d): Max() = %v, %v; expected %v\n", lo, hi, max, errMax, expected[len(expected)-1])
+		}
+	}
+}
+
+func TestViewInsertDelete(t *testing.T) {
+	tree := newIntegerTree(t, 1, 3, 5, 7, 9)
+	v := tree.View(Integer(3), Integer(8))
+
+	for _, key := range []int{2, 8, 10} {
+		if err := v.Insert(Integer(key)); err == nil {
+			t.Errorf("\tExpected an error for inserting %d!\n", key)
+		}
+	}
+	for _, key := range []int{1, 9} {
+		if err := v.Delete(Integer(key)); err == nil {
+			t.Errorf("\tExpected an error for deleting %d!\n", key)
+		} else {
+			t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+		}
+	}
+	if err := v.Insert(Integer(4)); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if err := v.Delete(Integer(7)); err != nil {
+		t.Errorf("\t%v\n", err)
+	}
+	if err := v.Insert(Integer(5)); err == nil {
+		t.Errorf("\tExpected an error for inserting a duplicate key!\n")
+	}
+	verifyTree(t, tree, []int{1, 3, 4, 5, 9})
+	verifyItems(t, v.InOrder(), 3, 4, 5)
+
+	// The view is live: changes to the tree are visible through it.
+	tree.Insert(Integer(6))
+	tree.Delete(Integer(3))
+	verifyItems(t, v.InOrder(), 4, 5, 6)
+	if v.Contains(Integer(1)) || !v.Contains(Integer(6)) {
+		t.Errorf("Contains does not respect the bounds of the view\n")
+	}
+
+	sub := v.View(Integer(0), Integer(6))
+	if lo, hi := sub.Bounds(); lo != Integer(3) || hi != Integer(6) {
+		t.Errorf("sub-view bounds are [%v, %v); expected [3, 6)\n", lo, hi)
+	}
+	visited := []Item{}
+	v.AscendRange(Integer(5), nil, func(key Item) bool {
+		visited = append(visited, key)
+		return true
+	})
+	verifyItems(t, visited, 5, 6)
+}
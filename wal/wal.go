@@ -0,0 +1,425 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+// Package wal implements a durable in-memory AVL tree, whose mutations are
+// recorded in a write-ahead log.
+//
+// A DurableTree is stored in a directory, which holds a snapshot of the keys
+// of the tree and a log of the mutations performed since the snapshot was
+// taken. Each Insert or Delete is appended to the log before it is applied to
+// the tree in memory. Opening the directory loads the snapshot and replays
+// the log. Checkpoint takes a new snapshot and truncates the log, so that it
+// does not grow without bounds.
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ckatsak/goavl"
+)
+
+// Names of the files in the directory of a DurableTree.
+const (
+	logName      = "log"
+	snapshotName = "snapshot"
+	tempName     = "snapshot.tmp"
+)
+
+// Kinds of log records.
+const (
+	opInsert = 'I'
+	opDelete = 'D'
+)
+
+// recordHeaderSize is the size of the header of log records, which consists
+// of the length of the payload and its CRC-32 checksum. The payload consists
+// of the kind of the record and the encoded key.
+const recordHeaderSize = 8
+
+var snapshotMagic = []byte("goavlss\x01")
+
+// SyncPolicy determines when the log is flushed to stable storage.
+type SyncPolicy int
+
+const (
+	// SyncAlways syncs the log after appending each record, so that each
+	// mutation is durable by the time Insert or Delete returns.
+	SyncAlways SyncPolicy = iota
+	// SyncNever leaves syncing the log to explicit calls of Sync (as well as
+	// Checkpoint and Close), trading the durability of the latest mutations
+	// for speed. A crash may lose them, but never corrupts the tree.
+	SyncNever
+)
+
+// Options configure the opening of a DurableTree.
+type Options struct {
+	Sync SyncPolicy
+}
+
+// DurableTree is an in-memory AVL tree whose mutations are recorded in a
+// write-ahead log, so that they survive restarts. It is not safe for
+// concurrent use.
+//
+// If writing to the log fails, the DurableTree stops accepting mutations,
+// and all of them return the same error; the directory then has to be opened
+// again, to recover the mutations that reached the log.
+type DurableTree struct {
+	dir   string
+	codec goavl.KeyCodec
+	opts  Options
+	tree  *goavl.Tree
+	log   *os.File
+	err   error
+}
+
+// Open opens the DurableTree stored in dir, creating the directory if it does
+// not exist, whose keys are encoded with codec. If opts is nil, the default
+// Options are used. The log is replayed on top of the snapshot; a torn or
+// corrupted record at its end, e.g. due to a crash while appending it, is
+// discarded along with anything after it.
+func Open(dir string, codec goavl.KeyCodec, opts *Options) (*DurableTree, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	_, err := os.Stat(dir)
+	created := errors.Is(err, os.ErrNotExist)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	if created {
+		// Make the new directory itself durable, along with the log below.
+		if err := syncDir(filepath.Dir(dir)); err != nil {
+			return nil, err
+		}
+	}
+	t := &DurableTree{dir: dir, codec: codec, opts: *opts, tree: goavl.NewTree()}
+	if err := t.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	log, err := os.OpenFile(filepath.Join(dir, logName), os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	t.log = log
+	if err := syncDir(dir); err != nil {
+		log.Close()
+		return nil, err
+	}
+	if err := t.replay(); err != nil {
+		log.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// loadSnapshot loads the keys of the snapshot, if there is one.
+func (t *DurableTree) loadSnapshot() error {
+	f, err := os.Open(filepath.Join(t.dir, snapshotName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	crc := crc32.NewIEEE()
+	tr := io.TeeReader(r, crc)
+	header := make([]byte, len(snapshotMagic)+8)
+	if _, err := io.ReadFull(tr, header); err != nil || string(header[:len(snapshotMagic)]) != string(snapshotMagic) {
+		return fmt.Errorf("Invalid snapshot: %s", f.Name())
+	}
+	// The count and the lengths of the keys are checked against the size of
+	// the file before allocating anything, since they may be corrupt; each
+	// key takes at least the 4 bytes of its length.
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	count := binary.BigEndian.Uint64(header[len(snapshotMagic):])
+	if count > uint64(info.Size())/4 {
+		return fmt.Errorf("Invalid snapshot: %s", f.Name())
+	}
+	keys := make([]goavl.Item, count)
+	var lenBuf [4]byte
+	for i := range keys {
+		if _, err := io.ReadFull(tr, lenBuf[:]); err != nil {
+			return fmt.Errorf("Reading snapshot: %v", err)
+		}
+		n := binary.BigEndian.Uint32(lenBuf[:])
+		if int64(n) > info.Size() {
+			return fmt.Errorf("Invalid snapshot: %s", f.Name())
+		}
+		raw := make([]byte, n)
+		if _, err := io.ReadFull(tr, raw); err != nil {
+			return fmt.Errorf("Reading snapshot: %v", err)
+		}
+		if keys[i], err = t.codec.Decode(raw); err != nil {
+			return fmt.Errorf("Decoding snapshot: %v", err)
+		}
+	}
+	sum := crc.Sum32()
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil || binary.BigEndian.Uint32(lenBuf[:]) != sum {
+		return fmt.Errorf("Invalid snapshot checksum: %s", f.Name())
+	}
+
+	for _, key := range keys {
+		if err := t.tree.Insert(key); err != nil {
+			return fmt.Errorf("Invalid snapshot: %v", err)
+		}
+	}
+	return nil
+}
+
+// replay applies the records of the log to the tree, and truncates the log
+// after the last valid one.
+func (t *DurableTree) replay() error {
+	r := bufio.NewReader(t.log)
+	var offset int64
+	for {
+		kind, key, n, err := t.readRecord(r)
+		if err != nil {
+			break
+		}
+		// Records that have already been applied to the snapshot (if a
+		// crash interrupted Checkpoint before truncating the log) fail
+		// harmlessly; replaying all of them yields the right keys anyway.
+		if kind == opInsert {
+			t.tree.Insert(key)
+		} else {
+			t.tree.Delete(key)
+		}
+		offset += int64(n)
+	}
+	if err := t.log.Truncate(offset); err != nil {
+		return err
+	}
+	_, err := t.log.Seek(offset, io.SeekStart)
+	return err
+}
+
+// readRecord reads a record from r and returns its kind, its key and its
+// size. It fails if the record is truncated or corrupted.
+func (t *DurableTree) readRecord(r io.Reader) (byte, goavl.Item, int, error) {
+	var header [recordHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, 0, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length == 0 || length > 1<<30 {
+		return 0, nil, 0, fmt.Errorf("Invalid record length: %d", length)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, 0, err
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+		return 0, nil, 0, fmt.Errorf("Invalid record checksum")
+	}
+	if kind := payload[0]; kind != opInsert && kind != opDelete {
+		return 0, nil, 0, fmt.Errorf("Invalid record kind: %#x", kind)
+	}
+	key, err := t.codec.Decode(payload[1:])
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	return payload[0], key, recordHeaderSize + len(payload), nil
+}
+
+// appendRecord appends a record of the given kind and key to the log.
+func (t *DurableTree) appendRecord(kind byte, key goavl.Item) error {
+	if t.err != nil {
+		return t.err
+	}
+	raw, err := t.codec.Encode(key)
+	if err != nil {
+		return err
+	}
+	rec := make([]byte, recordHeaderSize, recordHeaderSize+1+len(raw))
+	rec = append(append(rec, kind), raw...)
+	binary.BigEndian.PutUint32(rec[:4], uint32(1+len(raw)))
+	binary.BigEndian.PutUint32(rec[4:], crc32.ChecksumIEEE(rec[recordHeaderSize:]))
+	if _, err := t.log.Write(rec); err != nil {
+		t.err = err
+		return err
+	}
+	if t.opts.Sync == SyncAlways {
+		if err := t.log.Sync(); err != nil {
+			t.err = err
+			return err
+		}
+	}
+	return nil
+}
+
+// Insert inserts a key into the DurableTree, after recording it in the log. It
+// returns an error value, which is non-nil if the key already exists in the
+// tree or if it cannot be recorded.
+func (t *DurableTree) Insert(key goavl.Item) error {
+	if t.tree.Contains(key) {
+		return fmt.Errorf("Key already in the tree: %v", key)
+	}
+	if err := t.appendRecord(opInsert, key); err != nil {
+		return err
+	}
+	return t.tree.Insert(key)
+}
+
+// Delete removes a key from the DurableTree, after recording it in the log. It
+// returns an error value, which is non-nil if the key doesn't exist in the
+// tree or if it cannot be recorded.
+func (t *DurableTree) Delete(key goavl.Item) error {
+	if !t.tree.Contains(key) {
+		return fmt.Errorf("Key not found in the tree: %v", key)
+	}
+	if err := t.appendRecord(opDelete, key); err != nil {
+		return err
+	}
+	return t.tree.Delete(key)
+}
+
+// Tree returns the in-memory AVL tree, for reading. It must not be modified
+// directly, since such modifications would not be recorded.
+func (t *DurableTree) Tree() *goavl.Tree {
+	return t.tree
+}
+
+// Size returns the current number of keys in the DurableTree.
+func (t *DurableTree) Size() int {
+	return t.tree.Size()
+}
+
+// Contains reports whether key exists in the DurableTree.
+func (t *DurableTree) Contains(key goavl.Item) bool {
+	return t.tree.Contains(key)
+}
+
+// Min returns the minimum key in the DurableTree; see goavl.Tree.Min.
+func (t *DurableTree) Min() (goavl.Item, error) {
+	return t.tree.Min()
+}
+
+// Max returns the maximum key in the DurableTree; see goavl.Tree.Max.
+func (t *DurableTree) Max() (goavl.Item, error) {
+	return t.tree.Max()
+}
+
+// InOrder returns a slice of all keys in the DurableTree, in ascending order.
+func (t *DurableTree) InOrder() []goavl.Item {
+	return t.tree.InOrder()
+}
+
+// AscendRange calls visit for each key in [lo, hi); see
+// goavl.Tree.AscendRange.
+func (t *DurableTree) AscendRange(lo, hi goavl.Item, visit func(goavl.Item) bool) {
+	t.tree.AscendRange(lo, hi, visit)
+}
+
+// Sync flushes the log to stable storage.
+func (t *DurableTree) Sync() error {
+	if t.err != nil {
+		return t.err
+	}
+	return t.log.Sync()
+}
+
+// Checkpoint writes a snapshot of the keys of the DurableTree, which replaces
+// the previous one atomically, and then truncates the log.
+func (t *DurableTree) Checkpoint() error {
+	if t.err != nil {
+		return t.err
+	}
+	if err := t.writeSnapshot(); err != nil {
+		return err
+	}
+	if err := t.log.Truncate(0); err != nil {
+		t.err = err
+		return err
+	}
+	if _, err := t.log.Seek(0, io.SeekStart); err != nil {
+		t.err = err
+		return err
+	}
+	return t.log.Sync()
+}
+
+// writeSnapshot writes the keys of the tree into a temporary file, which it
+// then renames to the snapshot.
+func (t *DurableTree) writeSnapshot() error {
+	temp := filepath.Join(t.dir, tempName)
+	f, err := os.Create(temp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp) // fails harmlessly after the rename
+
+	crc := crc32.NewIEEE()
+	bw := bufio.NewWriter(f)
+	w := io.MultiWriter(bw, crc)
+	w.Write(snapshotMagic)
+	w.Write(binary.BigEndian.AppendUint64(nil, uint64(t.tree.Size())))
+	t.tree.AscendRange(nil, nil, func(key goavl.Item) bool {
+		var raw []byte
+		if raw, err = t.codec.Encode(key); err != nil {
+			return false
+		}
+		w.Write(binary.BigEndian.AppendUint32(nil, uint32(len(raw))))
+		w.Write(raw)
+		return true
+	})
+	if err == nil {
+		bw.Write(binary.BigEndian.AppendUint32(nil, crc.Sum32()))
+		err = bw.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(temp, filepath.Join(t.dir, snapshotName))
+	}
+	if err == nil {
+		err = syncDir(t.dir)
+	}
+	return err
+}
+
+// syncDir flushes the entries of the directory dir to stable storage.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Close syncs the log and closes it.
+func (t *DurableTree) Close() error {
+	err := t.Sync()
+	if cerr := t.log.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		t.err = fmt.Errorf("Tree is closed")
+	}
+	return err
+}
@@ -0,0 +1,163 @@
+/*
+Copyright (C) 2017, Christos Katsakioris
+All rights reserved.
+
+This software may be modified and distributed under the terms
+of the BSD 2-Clause License. See the LICENSE file for details.
+*/
+
+package wal
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/ckatsak/goavl"
+)
+
+func open(t *testing.T, dir string, opts *Options) *DurableTree {
+	t.Helper()
+	tree, err := Open(dir, goavl.IntCodec, opts)
+	if err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	return tree
+}
+
+// verify checks that tree holds exactly keys.
+func verify(t *testing.T, tree *DurableTree, keys map[int]bool) {
+	t.Helper()
+	expected := []int{}
+	for key, present := range keys {
+		if present {
+			expected = append(expected, key)
+		}
+	}
+	sort.Ints(expected)
+	got := tree.InOrder()
+	if len(got) != len(expected) {
+		t.Fatalf("tree holds %d keys; expected %d\n", len(got), len(expected))
+	}
+	for i, key := range expected {
+		if got[i] != goavl.Int(key) {
+			t.Fatalf("InOrder()[%d] = %v; expected %d\n", i, got[i], key)
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	dir := t.TempDir()
+	tree := open(t, dir, nil)
+	keys := map[int]bool{}
+	for i := 0; i < 500; i++ {
+		key := rand.Intn(100)
+		if rand.Intn(3) == 0 {
+			if err := tree.Delete(goavl.Int(key)); (err == nil) != keys[key] {
+				t.Fatalf("Delete(%d) = %v\n", key, err)
+			}
+			keys[key] = false
+		} else {
+			if err := tree.Insert(goavl.Int(key)); (err == nil) == keys[key] {
+				t.Fatalf("Insert(%d) = %v\n", key, err)
+			}
+			keys[key] = true
+		}
+	}
+	if err := tree.Close(); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	if err := tree.Insert(goavl.Int(-1)); err == nil {
+		t.Errorf("\tExpected an error for inserting into a closed tree!\n")
+	}
+
+	tree = open(t, dir, &Options{Sync: SyncNever})
+	defer tree.Close()
+	verify(t, tree, keys)
+}
+
+func TestTornTail(t *testing.T) {
+	dir := t.TempDir()
+	tree := open(t, dir, nil)
+	for i := 0; i < 10; i++ {
+		tree.Insert(goavl.Int(i))
+	}
+	tree.log.Close() // crash
+
+	// Tear the last record, and then append garbage after it.
+	path := filepath.Join(dir, logName)
+	info, _ := os.Stat(path)
+	os.Truncate(path, info.Size()-3)
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.Write([]byte{0, 0, 0, 4, 1, 2, 3, 4, 'I', 0, 0})
+	f.Close()
+
+	tree = open(t, dir, nil)
+	keys := map[int]bool{}
+	for i := 0; i < 9; i++ {
+		keys[i] = true
+	}
+	verify(t, tree, keys)
+
+	// The log is truncated, so that new records are not lost after the
+	// garbage.
+	tree.Insert(goavl.Int(100))
+	keys[100] = true
+	tree.Close()
+	tree = open(t, dir, nil)
+	defer tree.Close()
+	verify(t, tree, keys)
+}
+
+func TestCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	tree := open(t, dir, nil)
+	keys := map[int]bool{}
+	for i := 0; i < 50; i++ {
+		tree.Insert(goavl.Int(i))
+		keys[i] = true
+	}
+	if err := tree.Checkpoint(); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	if info, _ := os.Stat(filepath.Join(dir, logName)); info.Size() != 0 {
+		t.Errorf("log holds %d bytes after Checkpoint\n", info.Size())
+	}
+	for i := 0; i < 50; i += 2 {
+		tree.Delete(goavl.Int(i))
+		keys[i] = false
+	}
+	tree.Insert(goavl.Int(1000))
+	keys[1000] = true
+
+	// Simulate a crash after the next snapshot was written but before the
+	// log was truncated: the log is then replayed on top of the snapshot.
+	log, _ := os.ReadFile(filepath.Join(dir, logName))
+	if err := tree.Checkpoint(); err != nil {
+		t.Fatalf("\t%v\n", err)
+	}
+	tree.Close()
+	os.WriteFile(filepath.Join(dir, logName), log, 0666)
+
+	tree = open(t, dir, nil)
+	defer tree.Close()
+	verify(t, tree, keys)
+
+	// Corrupt counts and lengths must be rejected rather than allocated.
+	snapshot, _ := os.ReadFile(filepath.Join(dir, snapshotName))
+	count := len(snapshotMagic)
+	for _, corrupt := range [][]byte{
+		[]byte("garbage"),
+		append(append(append([]byte{}, snapshot[:count]...), 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff), snapshot[count+8:]...),
+		append(append(append([]byte{}, snapshot[:count+8]...), 0xff, 0xff, 0xff, 0xff), snapshot[count+12:]...),
+	} {
+		os.WriteFile(filepath.Join(dir, snapshotName), corrupt, 0666)
+		if _, err := Open(dir, goavl.IntCodec, nil); err == nil {
+			t.Errorf("\tExpected an error for a corrupted snapshot!\n")
+		} else {
+			t.Logf("\tError value returned, as expected: \"%v\"\n", err)
+		}
+	}
+}